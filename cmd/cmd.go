@@ -19,6 +19,8 @@ type Flags struct {
 	ci        bool
 	preCommit bool
 	dirty     bool
+	php       string
+	canonical bool
 }
 
 var flags = Flags{}
@@ -27,6 +29,7 @@ var gint = &cobra.Command{
 	Use:     "gint [path...]",
 	Example: "  gint app bootstrap/index.php --dirty --config pint.json",
 	Short:   "PHP formatter and linter",
+	Args:    cobra.ArbitraryArgs,
 	Long:    fmt.Sprintf(`%s is a blazingly fast CLI tool for linting and formatting PHP files.`, theme.Green.Render("gint")),
 	Run: func(cmd *cobra.Command, args []string) {
 		if flags.version {
@@ -39,16 +42,7 @@ var gint = &cobra.Command{
 			return
 		}
 
-		config, err := configurator.NewConfig(flags.config)
-
-		if err != nil {
-			adding := ""
-			if flags.config != "" {
-				adding = " from provided file"
-			}
-			logger.Fatal(fmt.Sprintf("errors settings gint configuration%s", adding))
-		}
-
+		config := loadConfig()
 		files := indexer.GetFiles(args, flags.dirty, config)
 
 		if flags.test {
@@ -64,6 +58,30 @@ var gint = &cobra.Command{
 	},
 }
 
+func loadConfig() *configurator.Config {
+	config, err := configurator.NewConfig(flags.config)
+
+	if err != nil {
+		adding := ""
+		if flags.config != "" {
+			adding = " from provided file"
+		}
+		logger.Fatal(fmt.Sprintf("errors settings gint configuration%s", adding))
+	}
+
+	if flags.canonical {
+		config.Canonical = true
+	}
+
+	if flags.php != "" {
+		if err := config.SetPHP(flags.php); err != nil {
+			logger.Fatal(err.Error())
+		}
+	}
+
+	return config
+}
+
 func init() {
 	gint.PersistentFlags().StringVar(&flags.config, "config", "", "Path to config file")
 	gint.PersistentFlags().BoolVar(&flags.test, "test", false, "Test without fixing")
@@ -72,6 +90,8 @@ func init() {
 	gint.PersistentFlags().BoolVarP(&flags.preCommit, "pre-commit", "p", false, "Append the gint lint action to the pre-commit file")
 	gint.PersistentFlags().BoolVarP(&flags.dirty, "dirty", "d", false, "Check git uncommited files only")
 	gint.PersistentFlags().BoolVarP(&flags.version, "version", "V", false, "Prints gint version")
+	gint.PersistentFlags().StringVar(&flags.php, "php", "", "Target PHP version, from 7.4 to 8.4")
+	gint.PersistentFlags().BoolVar(&flags.canonical, "canonical", false, "Reprint whole files in the canonical PSR-12 layout")
 
 	gint.SetUsageTemplate(UsageTemplate())
 }
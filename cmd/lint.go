@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/byawitz/gint/internal/commands"
+	"github.com/byawitz/gint/internal/indexer"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+type LintFlags struct {
+	syntax bool
+	psr4   bool
+}
+
+var lintFlags = LintFlags{}
+
+var lint = &cobra.Command{
+	Use:     "lint [path...]",
+	Example: "  gint lint app tests --syntax --psr4",
+	Short:   "Check PHP files without formatting them",
+	Long:    "Check PHP files without formatting them. Without flags, every check runs, the PSR-4 one when the project has a composer.json.",
+	Args:    cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := loadConfig()
+		files := indexer.GetFiles(args, flags.dirty, config)
+
+		all := !lintFlags.syntax && !lintFlags.psr4
+		passed := true
+
+		if all || lintFlags.syntax {
+			passed = commands.Syntax(files, config) && passed
+		}
+		if lintFlags.psr4 || all && commands.HasComposer(config) {
+			passed = commands.PSR4(files, config) && passed
+		}
+
+		if !passed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	lint.Flags().BoolVar(&lintFlags.syntax, "syntax", false, "Check the syntax of every file, like php -l")
+	lint.Flags().BoolVar(&lintFlags.psr4, "psr4", false, "Check classes match their path in the composer.json PSR-4 map")
+
+	gint.AddCommand(lint)
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/byawitz/gint/internal/commands"
+	"github.com/spf13/cobra"
+)
+
+type TokensFlags struct {
+	json bool
+}
+
+var tokensFlags = TokensFlags{}
+
+var tokens = &cobra.Command{
+	Use:     "tokens <file>",
+	Example: "  gint tokens app/Models/User.php --json",
+	Short:   "Print the token stream of a PHP file",
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		commands.Tokens(args[0], tokensFlags.json, loadConfig())
+	},
+}
+
+func init() {
+	tokens.Flags().BoolVar(&tokensFlags.json, "json", false, "Print the tokens as JSON shaped like PHP's token_get_all()")
+
+	gint.AddCommand(tokens)
+}
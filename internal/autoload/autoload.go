@@ -0,0 +1,108 @@
+// Package autoload reads the PSR-4 autoloading map of a project from its composer.json.
+package autoload
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Mapping maps a namespace prefix, like "App\", to the directory its classes are in.
+type Mapping struct {
+	Prefix    string
+	Directory string
+}
+
+// PSR4 is the PSR-4 map of a project, deepest directories first.
+type PSR4 []Mapping
+
+type composer struct {
+	Autoload    section `json:"autoload"`
+	AutoloadDev section `json:"autoload-dev"`
+}
+
+type section struct {
+	PSR4 map[string]json.RawMessage `json:"psr-4"`
+}
+
+// Read reads the "psr-4" maps of the "autoload" and "autoload-dev" sections of the
+// composer.json in root. Directories are made absolute from root.
+func Read(root string) (PSR4, error) {
+	path := filepath.Join(root, "composer.json")
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var project composer
+	if err := json.Unmarshal(content, &project); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	base, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var psr4 PSR4
+	for _, s := range []section{project.Autoload, project.AutoloadDev} {
+		for prefix, raw := range s.PSR4 {
+			directories, err := decodeDirectories(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: psr-4 directories of %q: %w", path, prefix, err)
+			}
+
+			for _, directory := range directories {
+				psr4 = append(psr4, Mapping{Prefix: prefix, Directory: filepath.Join(base, directory)})
+			}
+		}
+	}
+
+	sort.SliceStable(psr4, func(i, j int) bool {
+		if len(psr4[i].Directory) != len(psr4[j].Directory) {
+			return len(psr4[i].Directory) > len(psr4[j].Directory)
+		}
+		return psr4[i].Prefix < psr4[j].Prefix
+	})
+
+	return psr4, nil
+}
+
+// decodeDirectories decodes the directories of a prefix, given as a string or a list.
+func decodeDirectories(raw json.RawMessage) ([]string, error) {
+	var directory string
+	if err := json.Unmarshal(raw, &directory); err == nil {
+		return []string{directory}, nil
+	}
+
+	var directories []string
+	if err := json.Unmarshal(raw, &directories); err != nil {
+		return nil, fmt.Errorf("expected a directory or a list of directories")
+	}
+	return directories, nil
+}
+
+// Class returns the fully qualified name, without a leading backslash, the class of the
+// file must have to be autoloaded, and false if the file isn't in a PSR-4 directory.
+func (p PSR4) Class(file string) (string, bool) {
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return "", false
+	}
+
+	for _, mapping := range p {
+		relative, err := filepath.Rel(mapping.Directory, file)
+		if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		name := strings.TrimSuffix(relative, filepath.Ext(relative))
+		return strings.TrimPrefix(mapping.Prefix, `\`) + strings.ReplaceAll(name, string(filepath.Separator), `\`), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,57 @@
+package autoload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	root := t.TempDir()
+	composer := `{
+  "autoload": {"psr-4": {"App\\": "app/", "App\\Domain\\": "src/domain"}},
+  "autoload-dev": {"psr-4": {"Tests\\": ["tests/", "tests-e2e/"]}}
+}`
+	if err := os.WriteFile(filepath.Join(root, "composer.json"), []byte(composer), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	psr4, err := Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file, class string
+		ok          bool
+	}{
+		{"app/Models/User.php", `App\Models\User`, true},
+		{"app/Kernel.php", `App\Kernel`, true},
+		{"src/domain/Order/Order.php", `App\Domain\Order\Order`, true},
+		{"tests/Unit/UserTest.php", `Tests\Unit\UserTest`, true},
+		{"tests-e2e/LoginTest.php", `Tests\LoginTest`, true},
+		{"routes/web.php", "", false},
+		{"application/Foo.php", "", false},
+	}
+
+	for _, test := range tests {
+		class, ok := psr4.Class(filepath.Join(root, test.file))
+		if class != test.class || ok != test.ok {
+			t.Errorf("%s: expected %q, %v, got %q, %v", test.file, test.class, test.ok, class, ok)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := Read(t.TempDir()); !os.IsNotExist(err) {
+		t.Errorf("expected a missing composer.json to be reported, got %v", err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "composer.json"), []byte(`{"autoload": {"psr-4": {"App\\": 1}}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(root); err == nil {
+		t.Errorf("expected invalid directories to be reported")
+	}
+}
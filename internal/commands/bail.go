@@ -6,5 +6,5 @@ import (
 )
 
 func Bail(ci bool, files []string, config *configurator.Config) {
-	fmt.Printf("Bail %v, %v, %s", ci, "", config)
+	fmt.Printf("Bail %v, %v, %v", ci, "", config)
 }
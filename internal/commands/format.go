@@ -1,35 +1,160 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/byawitz/gint/internal/configurator"
+	"github.com/byawitz/gint/internal/logger"
 	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"github.com/byawitz/gint/pkg/printer"
+	"github.com/byawitz/gint/pkg/source"
 	"os"
+	"strings"
 )
 
+type formatResult struct {
+	problems []string
+}
+
 func Format(ci bool, files []string, config *configurator.Config) {
-	ch := make(chan bool)
+	ch := make(chan formatResult)
 
 	for _, file := range files {
 		go func() {
-			content, err := os.ReadFile(file)
-			if err != nil {
-				ch <- false
-				return
-			}
-			lexer.Tokenize(string(content))
-			ch <- true
-			return
+			ch <- formatFile(file, config)
 		}()
 	}
 
-	for _ = range files {
-		val := <-ch
-		if val {
+	var problems []string
+	for range files {
+		result := <-ch
+		if len(result.problems) == 0 {
 			fmt.Print("V")
 		} else {
 			fmt.Print("X")
+			problems = append(problems, result.problems...)
+		}
+	}
+	fmt.Printf("lexerd through %d files\n", len(files))
+
+	for _, problem := range problems {
+		logger.Bad(problem)
+	}
+}
+
+// formatFile formats a single file and writes it back in its own encoding, byte order mark
+// and line endings, unless the configuration normalizes them. A file that can't be read or
+// lexed is reported without stopping the other files, and isn't written.
+func formatFile(file string, config *configurator.Config) (result formatResult) {
+	defer func() {
+		if err := recover(); err != nil {
+			result.problems = append(result.problems, fmt.Sprintf("file %s: %v", file, err))
+		}
+	}()
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		result.problems = append(result.problems, fmt.Sprintf("file %s: %v", file, err))
+		return
+	}
+
+	decoded, err := source.Decode(raw)
+	if err != nil {
+		result.problems = append(result.problems, fmt.Sprintf("file %s: %v", file, err))
+		return
+	}
+
+	tokens, diagnostics := lexer.TokenizeWithOptions(decoded.Content, lexerOptions(config))
+	if config.Canonical && len(diagnostics) == 0 {
+		tokens, diagnostics = reprint(tokens, lineEnding(decoded, config), config)
+	}
+	for _, diagnostic := range diagnostics {
+		result.problems = append(result.problems, fmt.Sprintf("file %s: syntax problem at %s, %s", file, diagnostic.Position, diagnostic.Message))
+	}
+	if len(diagnostics) > 0 {
+		return
+	}
+
+	formatted, err := encodeOutput(decoded, tokens, config)
+	if err != nil {
+		result.problems = append(result.problems, fmt.Sprintf("file %s: %v", file, err))
+		return
+	}
+
+	if !bytes.Equal(formatted, raw) {
+		if err := writeFile(file, formatted); err != nil {
+			result.problems = append(result.problems, fmt.Sprintf("file %s: %v", file, err))
 		}
 	}
-	fmt.Printf("lexerd through %d files", len(files))
+
+	return
+}
+
+// reprint lays the file of tokens out again from its syntax tree, for the canonical mode,
+// breaking lines with ending. A file with syntax errors isn't reprinted, the errors are
+// returned instead.
+func reprint(tokens []lexer.Token, ending source.LineEnding, config *configurator.Config) ([]lexer.Token, []lexer.Diagnostic) {
+	file, diagnostics := parser.ParseTokens(tokens, lexerOptions(config))
+	if len(diagnostics) > 0 {
+		return nil, diagnostics
+	}
+
+	layout := printer.PSR12
+	layout.LineEnding = ending
+
+	// Writing to a strings.Builder never fails.
+	var printed strings.Builder
+	_ = layout.Fprint(&printed, file)
+
+	return lexer.TokenizeWithOptions(printed.String(), lexerOptions(config))
+}
+
+// encodeOutput joins the formatted tokens back into a file encoded like decoded was, or as
+// the configuration asks for. Line breaks are written as they are, unless the configuration
+// asks for "lf" or "crlf".
+func encodeOutput(decoded source.File, tokens []lexer.Token, config *configurator.Config) ([]byte, error) {
+	if config.BOM == configurator.RemoveBOM {
+		decoded.BOM = false
+	}
+	if config.Encoding == configurator.UTF8 {
+		decoded.Encoding = source.UTF8
+	}
+
+	if config.LineEnding != configurator.Keep {
+		return decoded.Encode(source.ConvertLineEndings(tokens, lineEnding(decoded, config)))
+	}
+
+	var content strings.Builder
+	for _, token := range tokens {
+		content.WriteString(token.Value)
+	}
+	return decoded.Encode(content.String())
+}
+
+// lineEnding returns the line ending the configuration asks for, or the one most lines of
+// the file end with.
+func lineEnding(decoded source.File, config *configurator.Config) source.LineEnding {
+	switch config.LineEnding {
+	case configurator.LF:
+		return source.LF
+	case configurator.CRLF:
+		return source.CRLF
+	}
+	return decoded.LineEnding
+}
+
+// writeFile replaces the content of file, keeping its permissions.
+func writeFile(file string, content []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, content, info.Mode().Perm())
+}
+
+// lexerOptions returns the lexer options matching the configuration.
+func lexerOptions(config *configurator.Config) lexer.Options {
+	return lexer.Options{ShortOpenTag: config.ShortOpenTag, Version: config.Version}
 }
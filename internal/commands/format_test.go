@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"github.com/byawitz/gint/internal/configurator"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func formatContent(t *testing.T, content string, config string) string {
+	t.Helper()
+
+	parsed, err := configurator.Parse(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "file.php")
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := formatFile(file, parsed); len(result.problems) > 0 {
+		t.Fatalf("Formatting %q: %v", content, result.problems)
+	}
+
+	formatted, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(formatted)
+}
+
+func TestFormatKeepsEncoding(t *testing.T) {
+	sources := []string{
+		"\xEF\xBB\xBF<?php\r\necho 'x';\r\n",
+		"<p>caf\xE9</p>\n<?php echo 1;\n",
+		"<?php\recho 1;\r",
+		"<?php\r\n$a = 1;\r\n$b = 2;\n$c = 3;\r\n",
+	}
+
+	for _, source := range sources {
+		if got := formatContent(t, source, "{}"); got != source {
+			t.Errorf("Formatting %q: got %q, want it unchanged", source, got)
+		}
+	}
+}
+
+func TestFormatNormalizesEncoding(t *testing.T) {
+	source := "\xEF\xBB\xBF<p>caf\xE9</p>\r\n<?php echo \"a\r\nb\";\r\n"
+	expected := "<p>café</p>\r\n<?php echo \"a\r\nb\";\n"
+
+	got := formatContent(t, source, `{"encoding": "utf-8", "bom": "remove", "lineEnding": "lf"}`)
+	if got != expected {
+		t.Fatalf("Formatting %q: got %q, want %q", source, got, expected)
+	}
+}
+
+func TestFormatCanonical(t *testing.T) {
+	source := "<?php\r\nif($a){echo 1;}\r\n"
+	expected := "<?php\r\nif ($a) {\r\n    echo 1;\r\n}\r\n"
+
+	if got := formatContent(t, source, `{"canonical": true}`); got != expected {
+		t.Fatalf("Formatting %q: got %q, want %q", source, got, expected)
+	}
+}
+
+func TestFormatCanonicalSkipsSyntaxErrors(t *testing.T) {
+	parsed, err := configurator.Parse(`{"canonical": true}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "file.php")
+	source := "<?php\nif ($a {\n"
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := formatFile(file, parsed); len(result.problems) == 0 {
+		t.Errorf("expected the syntax error to be reported")
+	}
+
+	if content, _ := os.ReadFile(file); string(content) != source {
+		t.Errorf("expected the file to be left unchanged, got %q", content)
+	}
+}
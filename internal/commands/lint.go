@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/internal/configurator"
+	"github.com/byawitz/gint/internal/logger"
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"github.com/byawitz/gint/pkg/source"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Syntax checks the syntax of files like "php -l" does, parsing them on one worker per CPU,
+// without changing them. Errors are reported in the order of the files, worded like php's,
+// and it returns false if any was found.
+func Syntax(files []string, config *configurator.Config) bool {
+	results := checkSyntax(files, config, runtime.NumCPU())
+
+	if failed := report(results); failed > 0 {
+		logger.Bad(fmt.Sprintf("Errors parsing %d of %d files", failed, len(files)))
+		return false
+	}
+
+	logger.Good(fmt.Sprintf("No syntax errors detected in %d files", len(files)))
+	return true
+}
+
+// checkSyntax parses files on workers, and returns the syntax errors of each file, in the
+// order of files.
+func checkSyntax(files []string, config *configurator.Config, workers int) [][]string {
+	return checkFiles(files, workers, func(file string) []string {
+		return checkFileSyntax(file, config)
+	})
+}
+
+// checkFiles runs check on every file, on workers, and returns the problems of each file in
+// the order of files.
+func checkFiles(files []string, workers int, check func(file string) []string) [][]string {
+	results := make([][]string, len(files))
+	jobs := make(chan int)
+
+	var wait sync.WaitGroup
+	for range max(workers, 1) {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for i := range jobs {
+				results[i] = check(files[i])
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wait.Wait()
+
+	return results
+}
+
+// report prints the problems of every file, and returns the number of files with problems.
+func report(results [][]string) int {
+	failed := 0
+	for _, problems := range results {
+		if len(problems) > 0 {
+			failed++
+		}
+		for _, problem := range problems {
+			logger.Bad(problem)
+		}
+	}
+	return failed
+}
+
+// checkFileSyntax parses a single file and returns its syntax errors.
+func checkFileSyntax(file string, config *configurator.Config) []string {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return []string{fmt.Sprintf("Could not open input file: %s", file)}
+	}
+
+	decoded, err := source.Decode(raw)
+	if err != nil {
+		return []string{fmt.Sprintf("file %s: %v", file, err)}
+	}
+
+	_, diagnostics := parser.Parse(decoded.Content, lexerOptions(config))
+
+	problems := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		problems = append(problems, parseError(file, diagnostic))
+	}
+	return problems
+}
+
+// parseError words a diagnostic the way php reports a parse error, with the column added.
+func parseError(file string, diagnostic lexer.Diagnostic) string {
+	return fmt.Sprintf("PHP Parse error:  %s in %s on line %d, column %d", diagnostic.Message, file, diagnostic.Position.Line, diagnostic.Position.Column)
+}
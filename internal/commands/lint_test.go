@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/internal/configurator"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	config, err := configurator.Parse("{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sources := []string{
+		"<?php\necho 1;\n",
+		"<?php\nif ($a) {\n    echo 1\n}\n",
+		"<?php\nenum Suit {}\n",
+		"<p>html only</p>\n",
+	}
+
+	dir := t.TempDir()
+	var files []string
+	for i, content := range sources {
+		file := filepath.Join(dir, fmt.Sprintf("file%d.php", i))
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	files = append(files, filepath.Join(dir, "missing.php"))
+
+	results := checkSyntax(files, config, 2)
+
+	expected := [][]string{
+		nil,
+		{fmt.Sprintf(`PHP Parse error:  syntax error, unexpected token "}", expecting ";" in %s on line 4, column 1`, files[1])},
+		nil,
+		nil,
+		{fmt.Sprintf("Could not open input file: %s", files[4])},
+	}
+
+	for i := range files {
+		if fmt.Sprint(results[i]) != fmt.Sprint(expected[i]) {
+			t.Errorf("%s: expected %v, got %v", files[i], expected[i], results[i])
+		}
+	}
+}
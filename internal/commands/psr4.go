@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/internal/autoload"
+	"github.com/byawitz/gint/internal/configurator"
+	"github.com/byawitz/gint/internal/logger"
+	"github.com/byawitz/gint/pkg/parser"
+	"github.com/byawitz/gint/pkg/resolver"
+	"github.com/byawitz/gint/pkg/source"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// HasComposer reports whether the project of the configuration has a composer.json.
+func HasComposer(config *configurator.Config) bool {
+	_, err := os.Stat(filepath.Join(config.Root, "composer.json"))
+	return err == nil
+}
+
+// PSR4 checks that the files in the PSR-4 directories of composer.json declare one class
+// each, named after their path with the exact same case, so autoloading finds them on
+// case-sensitive filesystems too. It returns false if any file doesn't.
+func PSR4(files []string, config *configurator.Config) bool {
+	psr4, err := autoload.Read(config.Root)
+	if err != nil {
+		logger.Bad(fmt.Sprintf("Can't read the PSR-4 autoloading map: %v", err))
+		return false
+	}
+
+	results := checkFiles(files, runtime.NumCPU(), func(file string) []string {
+		return checkFilePSR4(file, psr4, config)
+	})
+
+	if failed := report(results); failed > 0 {
+		logger.Bad(fmt.Sprintf("%d of %d files don't follow PSR-4", failed, len(files)))
+		return false
+	}
+
+	logger.Good(fmt.Sprintf("No PSR-4 problems detected in %d files", len(files)))
+	return true
+}
+
+// checkFilePSR4 checks the classes of a single file against the class its path expects.
+// Files outside the PSR-4 directories or without classes are skipped, and files with syntax
+// errors are reported as not checked.
+func checkFilePSR4(file string, psr4 autoload.PSR4, config *configurator.Config) []string {
+	expected, ok := psr4.Class(file)
+	if !ok {
+		return nil
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return []string{fmt.Sprintf("Could not open input file: %s", file)}
+	}
+
+	decoded, err := source.Decode(raw)
+	if err != nil {
+		return []string{fmt.Sprintf("file %s: %v", file, err)}
+	}
+
+	tree, diagnostics := parser.Parse(decoded.Content, lexerOptions(config))
+	if len(diagnostics) > 0 {
+		return []string{fmt.Sprintf("file %s: can't be checked for PSR-4, it has syntax errors starting on line %d", file, diagnostics[0].Position.Line)}
+	}
+
+	var classes []*resolver.Declaration
+	for _, declaration := range resolver.Resolve(tree).Declarations {
+		if declaration.Kind == resolver.Class {
+			classes = append(classes, declaration)
+		}
+	}
+
+	switch {
+	case len(classes) == 0:
+		return nil
+	case len(classes) > 1:
+		names := make([]string, len(classes))
+		for i, class := range classes {
+			names[i] = class.Name
+		}
+		return []string{fmt.Sprintf("file %s: declares %d classes, %s, but PSR-4 expects one class per file", file, len(classes), strings.Join(names, ", "))}
+	}
+
+	class := classes[0]
+	line := class.Identifier.Start.Line
+	switch {
+	case class.Name == expected:
+		return nil
+	case strings.EqualFold(class.Name, expected):
+		return []string{fmt.Sprintf("file %s on line %d: class %s differs in case from %s, the name its path gives", file, line, class.Name, expected)}
+	}
+	return []string{fmt.Sprintf("file %s on line %d: class %s doesn't match its path, which expects %s", file, line, class.Name, expected)}
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/internal/autoload"
+	"github.com/byawitz/gint/internal/configurator"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPSR4(t *testing.T) {
+	config, err := configurator.Parse("{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	config.Root = root
+
+	files := map[string]string{
+		"composer.json":           `{"autoload": {"psr-4": {"App\\": "app/"}}}`,
+		"app/Models/User.php":     "<?php\nnamespace App\\Models;\n\nfinal class User {}\n",
+		"app/Models/Post.php":     "<?php\nnamespace App\\Models;\n\nclass post {}\n",
+		"app/Models/Status.php":   "<?php\nnamespace App\\Model;\n\nenum Status: string {}\n",
+		"app/Both.php":            "<?php\nnamespace App;\n\ninterface Both {}\ntrait Other {}\n",
+		"app/helpers.php":         "<?php\nfunction helper() {}\n",
+		"app/Broken.php":          "<?php\nclass {\n",
+		"routes/web.php":          "<?php\nclass Anything {}\n",
+		"app/Http/Controller.php": "<?php\nnamespace App\\Http;\n\nabstract class Controller {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	psr4, err := autoload.Read(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := func(name string) string {
+		return filepath.Join(root, name)
+	}
+
+	expected := map[string][]string{
+		"app/Models/User.php":     nil,
+		"app/Models/Post.php":     {fmt.Sprintf(`file %s on line 4: class App\Models\post differs in case from App\Models\Post, the name its path gives`, path("app/Models/Post.php"))},
+		"app/Models/Status.php":   {fmt.Sprintf(`file %s on line 4: class App\Model\Status doesn't match its path, which expects App\Models\Status`, path("app/Models/Status.php"))},
+		"app/Both.php":            {fmt.Sprintf(`file %s: declares 2 classes, App\Both, App\Other, but PSR-4 expects one class per file`, path("app/Both.php"))},
+		"app/helpers.php":         nil,
+		"app/Broken.php":          {fmt.Sprintf("file %s: can't be checked for PSR-4, it has syntax errors starting on line 2", path("app/Broken.php"))},
+		"routes/web.php":          nil,
+		"app/Http/Controller.php": nil,
+	}
+
+	for name, problems := range expected {
+		got := checkFilePSR4(path(name), psr4, config)
+		if fmt.Sprint(got) != fmt.Sprint(problems) {
+			t.Errorf("%s: expected %v, got %v", name, problems, got)
+		}
+	}
+}
@@ -6,5 +6,5 @@ import (
 )
 
 func Test(ci bool, files []string, config *configurator.Config) {
-	fmt.Printf("Test %v, %v, %s", ci, "files", config)
+	fmt.Printf("Test %v, %v, %v", ci, "files", config)
 }
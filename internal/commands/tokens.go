@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/byawitz/gint/internal/configurator"
+	"github.com/byawitz/gint/internal/logger"
+	"github.com/byawitz/gint/internal/theme"
+	"github.com/byawitz/gint/pkg/lexer"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// Tokens prints the token stream of a PHP file, either as a table or as JSON.
+func Tokens(file string, asJSON bool, config *configurator.Config) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Can't read %s", file))
+	}
+
+	tokens, diagnostics := lexer.TokenizeWithOptions(string(content), lexerOptions(config))
+
+	if asJSON {
+		err = writeTokensJSON(os.Stdout, tokens)
+	} else {
+		err = writeTokensTable(os.Stdout, tokens)
+	}
+
+	if err != nil {
+		logger.Fatal(fmt.Sprintf("Can't print the tokens of %s", file))
+	}
+
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, theme.Red.Render(fmt.Sprintf("file %s: syntax problem at %s, %s", file, diagnostic.Position, diagnostic.Message)))
+	}
+}
+
+// writeTokensTable writes one token per line with its position, kind and quoted value.
+func writeTokensTable(w io.Writer, tokens []lexer.Token) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(table, "POSITION\tKIND\tVALUE")
+	for _, token := range tokens {
+		if token.Kind == lexer.TEOF {
+			continue
+		}
+		fmt.Fprintf(table, "%s\t%s\t%q\n", token.Start, lexer.TokenKindString(token.Kind), token.Value)
+	}
+
+	return table.Flush()
+}
+
+// writeTokensJSON writes the tokens the way json_encode(token_get_all()) would, except that
+// token names are used instead of ids: one-character tokens are plain strings and every
+// other token is a [name, text, line] array.
+func writeTokensJSON(w io.Writer, tokens []lexer.Token) error {
+	list := make([]any, 0, len(tokens))
+
+	for _, token := range tokens {
+		if token.Kind == lexer.TEOF {
+			continue
+		}
+
+		if lexer.TokenKindIsCharacter(token.Kind) {
+			list = append(list, token.Value)
+			continue
+		}
+
+		list = append(list, []any{lexer.TokenKindString(token.Kind), token.Value, token.Start.Line})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(list)
+}
@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"github.com/byawitz/gint/pkg/lexer"
+	"strings"
+	"testing"
+)
+
+const tokensSource = "<?php\necho $a;\n"
+
+func TestTokensJSON(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := writeTokensJSON(&out, lexer.Tokenize(tokensSource)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[
+    [
+        "T_OPEN_TAG",
+        "<?php\n",
+        1
+    ],
+    [
+        "T_ECHO",
+        "echo",
+        2
+    ],
+    [
+        "T_WHITESPACE",
+        " ",
+        2
+    ],
+    [
+        "T_VARIABLE",
+        "$a",
+        2
+    ],
+    ";",
+    [
+        "T_WHITESPACE",
+        "\n",
+        2
+    ]
+]
+`
+
+	if out.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestTokensTable(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := writeTokensTable(&out, lexer.Tokenize(tokensSource)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected a header and 6 tokens, got:\n%s", out.String())
+	}
+
+	if lines[4] != `2:6       T_VARIABLE    "$a"` {
+		t.Fatalf("unexpected table row %q", lines[4])
+	}
+}
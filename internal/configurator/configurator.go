@@ -2,33 +2,67 @@ package configurator
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/byawitz/gint/pkg/lexer"
 	"path/filepath"
+	"slices"
 )
 
 type Config struct {
-	Preset   string   `json:"preset"`
-	RawRules any      `json:"rules"`
-	Exclude  []string `json:"exclude"`
-	NotName  []string `json:"notName"`
-	NotPath  []string `json:"notPath"`
+	Preset       string   `json:"preset"`
+	RawRules     any      `json:"rules"`
+	Exclude      []string `json:"exclude"`
+	NotName      []string `json:"notName"`
+	NotPath      []string `json:"notPath"`
+	ShortOpenTag bool     `json:"shortOpenTag"`
+	PHP          string   `json:"php"`
+
+	// Canonical reprints whole files from their syntax tree in the PSR-12 layout, rather
+	// than fixing their tokens.
+	Canonical bool `json:"canonical"`
+
+	// Encoding, BOM and LineEnding decide how formatted files are written. By default they
+	// keep what each file had; "utf-8", "remove" and "lf" or "crlf" normalize them instead.
+	Encoding   string `json:"encoding"`
+	BOM        string `json:"bom"`
+	LineEnding string `json:"lineEnding"`
+
+	// Version is the target PHP version parsed from PHP, the latest supported one by default.
+	Version lexer.Version `json:"-"`
+
+	// Root is the directory of the configuration file, or the working directory without one.
+	// The project's composer.json is looked for there.
+	Root string `json:"-"`
 }
 
 const (
 	defaultPreset = "psr12"
+
+	Keep      = "keep"
+	UTF8      = "utf-8"
+	RemoveBOM = "remove"
+	LF        = "lf"
+	CRLF      = "crlf"
 )
 
 func NewConfig(path string) (*Config, error) {
-	content := getFile(path)
+	content, path := getFile(path)
 
 	if content == "" {
-		return &Config{Preset: defaultPreset}, nil
+		return &Config{Preset: defaultPreset, PHP: lexer.LatestVersion.String(), Version: lexer.LatestVersion, Encoding: Keep, BOM: Keep, LineEnding: Keep, Root: "."}, nil
+	}
+
+	config, err := Parse(content)
+	if err != nil {
+		return nil, err
 	}
 
-	return Parse(content)
+	config.Root = filepath.Dir(path)
+	return config, nil
 }
 
 func Parse(configContent string) (*Config, error) {
-	config := &Config{}
+	config := &Config{Root: "."}
 
 	err := json.Unmarshal([]byte(configContent), config)
 
@@ -39,6 +73,15 @@ func Parse(configContent string) (*Config, error) {
 	if config.Preset == "" {
 		config.Preset = defaultPreset
 	}
+
+	if err := config.SetPHP(config.PHP); err != nil {
+		return nil, err
+	}
+
+	if err := config.setOutput(); err != nil {
+		return nil, err
+	}
+
 	config.NotPath = removeRelativePrefix(config.NotPath)
 	config.Exclude = removeRelativePrefix(config.Exclude)
 	config.NotName = removeRelativePrefix(config.NotName)
@@ -46,6 +89,50 @@ func Parse(configContent string) (*Config, error) {
 	return config, nil
 }
 
+// SetPHP sets the target PHP version from a version such as "8.1". An empty version selects
+// the latest supported one.
+func (c *Config) SetPHP(version string) error {
+	if version == "" {
+		c.PHP = lexer.LatestVersion.String()
+		c.Version = lexer.LatestVersion
+		return nil
+	}
+
+	parsed, err := lexer.ParseVersion(version)
+	if err != nil {
+		return err
+	}
+
+	c.PHP = parsed.String()
+	c.Version = parsed
+	return nil
+}
+
+// setOutput defaults the output settings to keep each file as it is, and checks their values.
+func (c *Config) setOutput() error {
+	settings := []struct {
+		name    string
+		value   *string
+		allowed []string
+	}{
+		{"encoding", &c.Encoding, []string{Keep, UTF8}},
+		{"bom", &c.BOM, []string{Keep, RemoveBOM}},
+		{"lineEnding", &c.LineEnding, []string{Keep, LF, CRLF}},
+	}
+
+	for _, setting := range settings {
+		if *setting.value == "" {
+			*setting.value = Keep
+		}
+
+		if !slices.Contains(setting.allowed, *setting.value) {
+			return fmt.Errorf("unsupported %s %q, expected one of %v", setting.name, *setting.value, setting.allowed)
+		}
+	}
+
+	return nil
+}
+
 func removeRelativePrefix(path []string) []string {
 	var fixed []string
 
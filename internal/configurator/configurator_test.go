@@ -1,6 +1,8 @@
 package configurator
 
 import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"os"
 	"testing"
 )
 
@@ -111,3 +113,70 @@ func TestParsingError(t *testing.T) {
 	}
 
 }
+
+func TestParsingShortOpenTag(t *testing.T) {
+	config, err := Parse(`{"shortOpenTag": true}`)
+	if err != nil {
+		t.Fatalf("Parsing shortOpenTag failed: %v", err)
+	}
+
+	if !config.ShortOpenTag {
+		t.Fatalf("Parsing shortOpenTag failed: got %v, want %v", config.ShortOpenTag, true)
+	}
+}
+
+func TestParsingPHPVersion(t *testing.T) {
+	config, err := Parse(`{"php": "8.1"}`)
+	if err != nil {
+		t.Fatalf("Parsing php failed: %v", err)
+	}
+
+	if config.Version != lexer.PHP81 {
+		t.Fatalf("Parsing php failed: got %v, want %v", config.Version, lexer.PHP81)
+	}
+
+	config, err = Parse(goodEmptyConfigExample)
+	if err != nil || config.Version != lexer.LatestVersion {
+		t.Fatalf("Parsing %v failed: got %v, want %v", goodEmptyConfigExample, config.Version, lexer.LatestVersion)
+	}
+
+	if _, err := Parse(`{"php": "5.6"}`); err == nil {
+		t.Fatalf("Parsing php 5.6 failed: got nil, want error")
+	}
+}
+
+func TestDefaultConfig(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	config, err := NewConfig("")
+	if err != nil {
+		t.Fatalf("Creating the default config failed: %v", err)
+	}
+
+	if config.Version != lexer.LatestVersion || config.PHP != lexer.LatestVersion.String() {
+		t.Fatalf("Creating the default config failed: got %v and %q, want %v", config.Version, config.PHP, lexer.LatestVersion)
+	}
+}
+
+func TestParsingOutput(t *testing.T) {
+	config, err := Parse(goodEmptyConfigExample)
+	if err != nil || config.Encoding != Keep || config.BOM != Keep || config.LineEnding != Keep {
+		t.Fatalf("Parsing %v failed: got %+v, want output kept", goodEmptyConfigExample, config)
+	}
+
+	config, err = Parse(`{"encoding": "utf-8", "bom": "remove", "lineEnding": "crlf"}`)
+	if err != nil || config.Encoding != UTF8 || config.BOM != RemoveBOM || config.LineEnding != CRLF {
+		t.Fatalf("Parsing output failed: got %+v, %v", config, err)
+	}
+
+	if _, err := Parse(`{"lineEnding": "cr"}`); err == nil {
+		t.Fatalf("Parsing lineEnding cr failed: got nil, want error")
+	}
+}
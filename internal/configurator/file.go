@@ -10,7 +10,9 @@ const (
 	pintConfigFilename = "pint.json"
 )
 
-func getFile(path string) string {
+// getFile returns the content of the configuration file at path, or of the gint.json or
+// pint.json in the working directory when path is empty, along with the path read.
+func getFile(path string) (string, string) {
 	if path == "" {
 		if _, err2 := os.Stat(gintConfigFilename); err2 == nil {
 			path = gintConfigFilename
@@ -19,7 +21,7 @@ func getFile(path string) string {
 		}
 
 		if path == "" {
-			return ""
+			return "", ""
 		}
 	}
 
@@ -29,5 +31,5 @@ func getFile(path string) string {
 		logger.Fatal("Error reading config file")
 	}
 
-	return string(config)
+	return string(config), path
 }
@@ -0,0 +1,77 @@
+package fixers
+
+import "github.com/byawitz/gint/pkg/lexer"
+
+// Feature is a piece of syntax a fixer may emit that older PHP versions can't parse.
+type Feature int
+
+const (
+	TrailingCommaInParameters Feature = iota
+	TrailingCommaInClosureUse
+	NullsafeOperator
+	MatchExpression
+	NamedArguments
+	ConstructorPromotion
+	UnionTypes
+	MixedType
+	StaticReturnType
+	Attributes
+	ThrowExpression
+	ClassConstantOnObjects
+	ExplicitOctalPrefix
+	Enums
+	ReadonlyProperties
+	NeverReturnType
+	IntersectionTypes
+	FirstClassCallable
+	NewInInitializers
+	ReadonlyClasses
+	DisjunctiveNormalFormTypes
+	StandaloneNullFalseTrueTypes
+	TypedClassConstants
+	DynamicClassConstantFetch
+	PropertyHooks
+	AsymmetricVisibility
+	NewWithoutParentheses
+)
+
+// featureVersions holds the PHP version that introduced each feature.
+var featureVersions = map[Feature]lexer.Version{
+	TrailingCommaInParameters:    lexer.PHP80,
+	TrailingCommaInClosureUse:    lexer.PHP80,
+	NullsafeOperator:             lexer.PHP80,
+	MatchExpression:              lexer.PHP80,
+	NamedArguments:               lexer.PHP80,
+	ConstructorPromotion:         lexer.PHP80,
+	UnionTypes:                   lexer.PHP80,
+	MixedType:                    lexer.PHP80,
+	StaticReturnType:             lexer.PHP80,
+	Attributes:                   lexer.PHP80,
+	ThrowExpression:              lexer.PHP80,
+	ClassConstantOnObjects:       lexer.PHP80,
+	ExplicitOctalPrefix:          lexer.PHP81,
+	Enums:                        lexer.PHP81,
+	ReadonlyProperties:           lexer.PHP81,
+	NeverReturnType:              lexer.PHP81,
+	IntersectionTypes:            lexer.PHP81,
+	FirstClassCallable:           lexer.PHP81,
+	NewInInitializers:            lexer.PHP81,
+	ReadonlyClasses:              lexer.PHP82,
+	DisjunctiveNormalFormTypes:   lexer.PHP82,
+	StandaloneNullFalseTrueTypes: lexer.PHP82,
+	TypedClassConstants:          lexer.PHP83,
+	DynamicClassConstantFetch:    lexer.PHP83,
+	PropertyHooks:                lexer.PHP84,
+	AsymmetricVisibility:         lexer.PHP84,
+	NewWithoutParentheses:        lexer.PHP84,
+}
+
+// Supports reports whether code targeting the given PHP version may use a feature. Fixers
+// check it before emitting new syntax, for example a trailing comma after the last parameter.
+func Supports(version lexer.Version, feature Feature) bool {
+	if version == 0 {
+		version = lexer.LatestVersion
+	}
+
+	return version >= featureVersions[feature]
+}
@@ -0,0 +1,29 @@
+package fixers
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"testing"
+)
+
+func TestSupports(t *testing.T) {
+	cases := []struct {
+		version  lexer.Version
+		feature  Feature
+		expected bool
+	}{
+		{lexer.PHP74, TrailingCommaInParameters, false},
+		{lexer.PHP80, TrailingCommaInParameters, true},
+		{lexer.PHP74, NullsafeOperator, false},
+		{lexer.PHP80, Enums, false},
+		{lexer.PHP81, Enums, true},
+		{lexer.PHP82, TypedClassConstants, false},
+		{lexer.PHP83, TypedClassConstants, true},
+		{0, PropertyHooks, true},
+	}
+
+	for _, c := range cases {
+		if got := Supports(c.version, c.feature); got != c.expected {
+			t.Errorf("Supports(%s, %d): got %v, want %v", c.version, c.feature, got, c.expected)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package lexer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Tokens is a mutable list of tokens, the one primitive fixers edit code with. It remembers
+// whether it was changed, so a fixer can tell if it did anything.
+//
+// The positions of the tokens are the ones they were lexed at. Edits don't update them, and
+// inserted tokens have none, so after an edit only the order of tokens can be relied on.
+type Tokens struct {
+	items   []Token
+	changed bool
+}
+
+// NewTokens returns a collection holding a copy of tokens.
+func NewTokens(tokens []Token) *Tokens {
+	return &Tokens{items: append([]Token(nil), tokens...)}
+}
+
+// Len returns the number of tokens.
+func (t *Tokens) Len() int {
+	return len(t.items)
+}
+
+// At returns the token at index i.
+func (t *Tokens) At(i int) Token {
+	return t.items[i]
+}
+
+// All returns the tokens. The slice must not be modified; use the collection's methods to
+// edit it.
+func (t *Tokens) All() []Token {
+	return t.items
+}
+
+// String returns the source code the tokens make up.
+func (t *Tokens) String() string {
+	var builder strings.Builder
+	for _, token := range t.items {
+		builder.WriteString(token.Value)
+	}
+	return builder.String()
+}
+
+// Changed reports whether the tokens were edited since the collection was created or
+// ClearChanged was last called.
+func (t *Tokens) Changed() bool {
+	return t.changed
+}
+
+func (t *Tokens) ClearChanged() {
+	t.changed = false
+}
+
+// Insert inserts tokens before index i. With i equal to Len they are appended.
+func (t *Tokens) Insert(i int, tokens ...Token) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	t.items = slices.Insert(t.items, i, tokens...)
+	t.changed = true
+}
+
+// Remove removes the tokens from index from up to, but not including, index to.
+func (t *Tokens) Remove(from, to int) {
+	if from == to {
+		return
+	}
+
+	t.items = slices.Delete(t.items, from, to)
+	t.changed = true
+}
+
+// Replace replaces the token at index i with tokens, which may be none.
+func (t *Tokens) Replace(i int, tokens ...Token) {
+	if len(tokens) == 1 && tokens[0].Kind == t.items[i].Kind && tokens[0].Value == t.items[i].Value {
+		return
+	}
+
+	t.items = slices.Replace(t.items, i, i+1, tokens...)
+	t.changed = true
+}
+
+// IsMeaningful reports whether the token at index i is code, rather than whitespace or a
+// comment.
+func (t *Tokens) IsMeaningful(i int) bool {
+	return !t.items[i].Kind.IsTrivia()
+}
+
+// NextMeaningful returns the index of the first meaningful token after index i, or -1 if
+// there is none.
+func (t *Tokens) NextMeaningful(i int) int {
+	for i++; i < len(t.items); i++ {
+		if t.IsMeaningful(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PreviousMeaningful returns the index of the last meaningful token before index i, or -1
+// if there is none.
+func (t *Tokens) PreviousMeaningful(i int) int {
+	for i--; i >= 0; i-- {
+		if t.IsMeaningful(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// blockOpeners maps each kind of token opening a block to the kind closing it. Interpolation
+// in strings, "{$" and "${", and attributes, "#[", close with the usual "}" and "]".
+var blockOpeners = map[Kind]Kind{
+	TOpenParen:             TCloseParen,
+	TOpenBracket:           TCloseBracket,
+	TOPENCurly:             TCloseCurly,
+	TCurlyOpen:             TCloseCurly,
+	TDollarOpenCurlyBraces: TCloseCurly,
+	TAttribute:             TCloseBracket,
+	TStartHeredoc:          TEndHeredoc,
+}
+
+// blockClosers holds the kinds of tokens closing a block.
+var blockClosers = map[Kind]bool{
+	TCloseParen:   true,
+	TCloseBracket: true,
+	TCloseCurly:   true,
+	TEndHeredoc:   true,
+}
+
+// IsBlockStart reports whether the token at index i opens a block: a parenthesis, bracket,
+// curly brace, attribute, interpolation or heredoc.
+func (t *Tokens) IsBlockStart(i int) bool {
+	_, ok := blockOpeners[t.items[i].Kind]
+	return ok
+}
+
+// IsBlockEnd reports whether the token at index i closes a block.
+func (t *Tokens) IsBlockEnd(i int) bool {
+	return blockClosers[t.items[i].Kind]
+}
+
+// BlockEnd returns the index of the token closing the block opened at index i. It panics
+// if the token at i doesn't open a block, and returns -1 if the block is never closed.
+func (t *Tokens) BlockEnd(i int) int {
+	if !t.IsBlockStart(i) {
+		panic(fmt.Sprintf("lexer: token %d, %s, doesn't open a block", i, TokenKindString(t.items[i].Kind)))
+	}
+
+	depth := 0
+	for j := i; j < len(t.items); j++ {
+		switch {
+		case t.IsBlockStart(j):
+			depth++
+		case t.IsBlockEnd(j):
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// BlockStart returns the index of the token opening the block closed at index i. It panics
+// if the token at i doesn't close a block, and returns -1 if the block is never opened.
+func (t *Tokens) BlockStart(i int) int {
+	if !t.IsBlockEnd(i) {
+		panic(fmt.Sprintf("lexer: token %d, %s, doesn't close a block", i, TokenKindString(t.items[i].Kind)))
+	}
+
+	depth := 0
+	for j := i; j >= 0; j-- {
+		switch {
+		case t.IsBlockEnd(j):
+			depth++
+		case t.IsBlockStart(j):
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return -1
+}
+
+// MatchingBrace returns the index of the token matching the parenthesis, bracket or brace at
+// index i, searching forwards from an opening one and backwards from a closing one. It
+// returns -1 if there is no match or the token at i isn't one of them.
+func (t *Tokens) MatchingBrace(i int) int {
+	switch {
+	case t.IsBlockStart(i):
+		return t.BlockEnd(i)
+	case t.IsBlockEnd(i):
+		return t.BlockStart(i)
+	}
+	return -1
+}
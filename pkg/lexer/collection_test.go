@@ -0,0 +1,85 @@
+package lexer
+
+import (
+	"testing"
+)
+
+func TestTokensEditing(t *testing.T) {
+	tokens := NewTokens(Tokenize("<?php foo($a,$b);"))
+	if tokens.Changed() {
+		t.Fatal("new tokens are changed")
+	}
+
+	comma := 4
+	if tokens.At(comma).Kind != TComma {
+		t.Fatalf("got %s at %d, want T_COMMA", TokenKindString(tokens.At(comma).Kind), comma)
+	}
+
+	tokens.Replace(comma, NewToken(TComma, ","))
+	if tokens.Changed() {
+		t.Fatal("replacing a token with the same one changed the tokens")
+	}
+
+	tokens.Insert(comma+1, NewToken(TWhitespace, " "))
+	tokens.Replace(1, NewToken(TString, "bar"))
+	tokens.Remove(tokens.Len()-2, tokens.Len()-1)
+
+	if got := tokens.String(); got != "<?php bar($a, $b)" {
+		t.Fatalf("got %q, want %q", got, "<?php bar($a, $b)")
+	}
+	if !tokens.Changed() {
+		t.Fatal("edited tokens aren't changed")
+	}
+
+	tokens.ClearChanged()
+	if tokens.Changed() {
+		t.Fatal("tokens still changed after ClearChanged")
+	}
+}
+
+func TestTokensMeaningful(t *testing.T) {
+	tokens := NewTokens(Tokenize("<?php $a /* c */ = // d\n 1;"))
+
+	if next := tokens.NextMeaningful(1); tokens.At(next).Kind != TAssignment {
+		t.Fatalf("next meaningful after $a: got %s", TokenKindString(tokens.At(next).Kind))
+	}
+	if previous := tokens.PreviousMeaningful(tokens.Len() - 3); tokens.At(previous).Kind != TAssignment {
+		t.Fatalf("previous meaningful before 1: got %s", TokenKindString(tokens.At(previous).Kind))
+	}
+	if next := tokens.NextMeaningful(tokens.Len() - 1); next != -1 {
+		t.Fatalf("next meaningful after the end: got %d, want -1", next)
+	}
+	if previous := tokens.PreviousMeaningful(0); previous != -1 {
+		t.Fatalf("previous meaningful before the start: got %d, want -1", previous)
+	}
+}
+
+func TestTokensBlocks(t *testing.T) {
+	tokens := NewTokens(Tokenize("<?php #[A([1])] function f() { return \"{$a[0]}\" . <<<EOT\n${b}\nEOT; }"))
+
+	for i := 0; i < tokens.Len(); i++ {
+		if !tokens.IsBlockStart(i) {
+			continue
+		}
+
+		end := tokens.BlockEnd(i)
+		if end == -1 {
+			t.Fatalf("block %s at %d isn't closed", TokenKindString(tokens.At(i).Kind), i)
+		}
+		if expected := blockOpeners[tokens.At(i).Kind]; tokens.At(end).Kind != expected {
+			t.Fatalf("block %s at %d: closed by %s, want %s", TokenKindString(tokens.At(i).Kind), i, TokenKindString(tokens.At(end).Kind), TokenKindString(expected))
+		}
+		if start := tokens.MatchingBrace(end); start != i {
+			t.Fatalf("block %s at %d: matching brace of its end is %d", TokenKindString(tokens.At(i).Kind), i, start)
+		}
+	}
+
+	if got := tokens.MatchingBrace(0); got != -1 {
+		t.Fatalf("matching brace of an open tag: got %d, want -1", got)
+	}
+
+	unclosed := NewTokens(Tokenize("<?php if ($a) {"))
+	if got := unclosed.BlockEnd(unclosed.Len() - 2); got != -1 {
+		t.Fatalf("end of an unclosed block: got %d, want -1", got)
+	}
+}
@@ -0,0 +1,62 @@
+package lexer
+
+import "strings"
+
+// lineCommentAt reports whether a "//" or "#" comment starts at offset i. Before PHP 8.0,
+// "#[" opens a comment rather than an attribute.
+func (l *lexer) lineCommentAt(i int) bool {
+	switch l.source[i] {
+	case '/':
+		return l.byteAt(i+1) == '/'
+	case '#':
+		return l.byteAt(i+1) != '[' || !l.supports(PHP80)
+	}
+	return false
+}
+
+// lineCommentLength returns the length of the "//" or "#" comment starting at offset i. It
+// stops right before a "?>" on the same line, and before the line break that ends it, which
+// php 8 leaves to the whitespace that follows. Before PHP 8.0, the comment takes in the
+// line break.
+func (l *lexer) lineCommentLength(i int) int {
+	lineBreak := 0
+	if !l.supports(PHP80) {
+		lineBreak = 1
+	}
+
+	for j := i; l.available(j); j++ {
+		switch l.source[j] {
+		case '\n':
+			return j + lineBreak - i
+		case '\r':
+			if lineBreak == 1 && l.byteAt(j+1) == '\n' {
+				return j + 2 - i
+			}
+			return j + lineBreak - i
+		case '?':
+			if l.byteAt(j+1) == '>' {
+				return j - i
+			}
+		}
+	}
+
+	return len(l.source) - i
+}
+
+// scanBlockComment lexes a "/* */" comment. Only comments opened by "/**" and whitespace are
+// doc comments, so "/**/" and "/***" decorations stay regular comments.
+func (l *lexer) scanBlockComment() {
+	end := l.index(l.pos+2, "*/")
+	if end == -1 {
+		l.report(l.position(), "unterminated comment")
+		l.emit(TComment, len(l.remainder()))
+		return
+	}
+
+	if strings.HasPrefix(l.remainder(), "/**") && isWhitespace(l.peek(3)) {
+		l.emit(TDocComment, end+2-l.pos)
+		return
+	}
+
+	l.emit(TComment, end+2-l.pos)
+}
@@ -0,0 +1,31 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic describes a problem found while lexing. Position is where the problem starts
+// and Snippet is the source text from there to the end of its line, shortened if needed.
+type Diagnostic struct {
+	Message  string
+	Position Position
+	Snippet  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s near %q", d.Position, d.Message, d.Snippet)
+}
+
+// report records a diagnostic at the given position.
+func (l *lexer) report(position Position, message string) {
+	snippet := l.source[max(position.Offset-l.base, 0):]
+	if end := strings.IndexAny(snippet, "\r\n"); end != -1 {
+		snippet = snippet[:end]
+	}
+	if len(snippet) > maxErrorShowingLength {
+		snippet = snippet[:maxErrorShowingLength] + "..."
+	}
+
+	l.diagnostics = append(l.diagnostics, Diagnostic{Message: message, Position: position, Snippet: snippet})
+}
@@ -0,0 +1,17 @@
+// Package lexer splits PHP source into tokens the way php's own tokenizer does with
+// token_get_all and TOKEN_PARSE, keeping every byte of the source.
+//
+// Tokenize and TokenizeWithOptions lex a whole source at once. Options pick the target PHP
+// version and whether short open tags are enabled. Lexing never fails: problems such as an
+// unterminated string are returned as diagnostics next to the tokens.
+//
+// For sources that don't fit in memory, a Scanner lexes from an io.Reader one token at a
+// time. For sources that change, like a file open in an editor, a Document lexes again only
+// the tokens around each edit.
+//
+// Fixers edit tokens through Tokens, a collection that can insert, remove and replace
+// tokens and find the meaningful tokens and blocks around an index.
+//
+// A Kind names a type of token. It prints, and encodes to JSON, as php's name for it, such
+// as "T_STRING", and can be classified with IsKeyword, IsOperator, IsCast and IsComment.
+package lexer
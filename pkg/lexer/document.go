@@ -0,0 +1,273 @@
+package lexer
+
+import (
+	"fmt"
+	"sort"
+)
+
+// checkpoint is the lexer state at the start of a token, kept so lexing can restart there.
+// Outside strings and heredocs the state stack only holds the scripting states pushed by
+// "{", so its depth is enough to rebuild it. A negative depth means lexing can't restart at
+// that token, because it is inside a literal, after __halt_compiler or in the middle of a
+// group of tokens lexed together.
+type checkpoint struct {
+	state state
+	depth int
+}
+
+var noCheckpoint = checkpoint{depth: -1}
+
+func (l *lexer) checkpoint() checkpoint {
+	if len(l.literals) > 0 || l.halt != -1 {
+		return noCheckpoint
+	}
+	return checkpoint{state: l.state, depth: len(l.stack)}
+}
+
+// Edit replaces the source between the byte offsets Start and End with Text.
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Change describes how an edit changed the tokens of a Document: Removed tokens starting at
+// Index were replaced by Inserted new ones. The tokens after them are unchanged apart from
+// their positions.
+type Change struct {
+	Index    int
+	Removed  int
+	Inserted int
+}
+
+// Document is PHP source kept together with its tokens, so that after an edit only the
+// tokens around it are lexed again, which is what an editor needs on every keystroke.
+type Document struct {
+	source      string
+	options     Options
+	tokens      []Token
+	checkpoints []checkpoint
+	diagnostics []Diagnostic
+}
+
+// NewDocument lexes source with the given options.
+func NewDocument(source string, options Options) *Document {
+	d := &Document{source: source, options: options}
+
+	lex := createLexer(source)
+	lex.options = options
+	d.checkpoints = lex.run(nil, 0)
+	lex.finish()
+	d.checkpoints = append(d.checkpoints, noCheckpoint)
+
+	d.tokens, d.diagnostics = lex.Tokens, lex.diagnostics
+	return d
+}
+
+// Source returns the current source of the document.
+func (d *Document) Source() string {
+	return d.source
+}
+
+// Tokens returns the tokens of the current source, the same ones Tokenize would return.
+// The slice must not be modified.
+func (d *Document) Tokens() []Token {
+	return d.tokens
+}
+
+// Diagnostics returns the problems found in the current source.
+func (d *Document) Diagnostics() []Diagnostic {
+	return d.diagnostics
+}
+
+// Apply edits the source and lexes it again, starting a little before the edit and
+// stopping as soon as the new tokens line up with the old ones again.
+// It panics if the edit is out of the source's range.
+func (d *Document) Apply(edit Edit) Change {
+	if edit.Start < 0 || edit.Start > edit.End || edit.End > len(d.source) {
+		panic(fmt.Sprintf("lexer: edit [%d:%d] out of range of %d bytes", edit.Start, edit.End, len(d.source)))
+	}
+
+	source := d.source[:edit.Start] + edit.Text + d.source[edit.End:]
+	restart := d.restartIndex(edit.Start)
+	from := d.tokens[restart].Start
+
+	lex := createLexer(source)
+	lex.options = d.options
+	lex.pos, lex.line, lex.column = from.Offset, from.Line, from.Column
+	lex.state = d.checkpoints[restart].state
+	for range d.checkpoints[restart].depth {
+		lex.stack = append(lex.stack, stateScripting)
+	}
+	lex.Tokens = append(lex.Tokens, d.tokens[lookbackIndex(d.tokens, restart):restart]...)
+	mark := len(lex.Tokens)
+
+	sync := &resync{document: d, delta: len(edit.Text) - (edit.End - edit.Start), after: edit.Start + len(edit.Text), mark: mark}
+	checkpoints := lex.run(sync, mark)
+
+	tokens := append([]Token(nil), d.tokens[:restart]...)
+	tokens = append(tokens, lex.Tokens[mark:]...)
+	checkpoints = append(append([]checkpoint(nil), d.checkpoints[:restart]...), checkpoints...)
+
+	var diagnostics []Diagnostic
+	for _, diagnostic := range d.diagnostics {
+		if diagnostic.Position.Offset < from.Offset {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	change := Change{Index: restart, Inserted: len(lex.Tokens) - mark}
+
+	if sync.index == -1 {
+		lex.finish()
+		tokens = append(tokens, lex.Tokens[len(lex.Tokens)-1])
+		checkpoints = append(checkpoints, noCheckpoint)
+		change.Removed = len(d.tokens) - restart
+		change.Inserted++
+		diagnostics = append(diagnostics, lex.diagnostics...)
+	} else {
+		was, now := d.tokens[sync.index].Start, lex.position()
+		for _, token := range d.tokens[sync.index:] {
+			token.Start = shift(token.Start, was, now)
+			token.End = shift(token.End, was, now)
+			tokens = append(tokens, token)
+		}
+		checkpoints = append(checkpoints, d.checkpoints[sync.index:]...)
+		change.Removed = sync.index - restart
+
+		diagnostics = append(diagnostics, lex.diagnostics...)
+		for _, diagnostic := range d.diagnostics {
+			if diagnostic.Position.Offset >= was.Offset {
+				diagnostic.Position = shift(diagnostic.Position, was, now)
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+	}
+
+	d.source, d.tokens, d.checkpoints, d.diagnostics = source, tokens, checkpoints, diagnostics
+	return change
+}
+
+// restartIndex returns the index of the token to lex again from for an edit at offset. A
+// token may depend on the next few tokens, like "(" in a cast or "<<" before a heredoc
+// label, so lexing restarts at the third significant token ending before the edit, or
+// earlier if the lexer can't restart there.
+func (d *Document) restartIndex(offset int) int {
+	i := sort.Search(len(d.tokens), func(i int) bool {
+		return d.tokens[i].End.Offset >= offset
+	})
+
+	i = lookbackIndex(d.tokens, i)
+	for i > 0 && d.checkpoints[i].depth < 0 {
+		i--
+	}
+	return max(i, 0)
+}
+
+// lookbackIndex returns the index of the third significant token before index i, which is
+// as far as the lexer looks back or ahead from a token.
+func lookbackIndex(tokens []Token, i int) int {
+	for significant := 0; i > 0 && significant < 3; {
+		i--
+		if !tokens[i].Kind.IsTrivia() {
+			significant++
+		}
+	}
+	return i
+}
+
+// resync finds where the tokens lexed after an edit line up with the old ones again.
+type resync struct {
+	document *Document
+	delta    int
+	after    int
+	mark     int
+	index    int
+}
+
+// match reports whether the old tokens from the one starting where the lexer stands in the
+// new source can be reused. The lexer must be past the edit, in the same state as when the
+// old token was lexed, and have the same significant tokens behind it.
+func (r *resync) match(lex *lexer) bool {
+	if lex.pos < r.after || lex.pos == r.after && len(lex.Tokens) == r.mark {
+		return false
+	}
+
+	state := lex.checkpoint()
+	if state == noCheckpoint {
+		return false
+	}
+
+	tokens := r.document.tokens
+	offset := lex.pos - r.delta
+	i := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Start.Offset >= offset
+	})
+	if i == len(tokens) || tokens[i].Start.Offset != offset || r.document.checkpoints[i] != state {
+		return false
+	}
+
+	before, after := i, len(lex.Tokens)
+	for significant := 0; significant < 2; significant++ {
+		before, after = previousSignificantIndex(tokens, before), previousSignificantIndex(lex.Tokens, after)
+		if before == -1 || after == -1 {
+			if before != after {
+				return false
+			}
+			break
+		}
+		if tokens[before].Kind != lex.Tokens[after].Kind || tokens[before].Value != lex.Tokens[after].Value {
+			return false
+		}
+	}
+
+	r.index = i
+	return true
+}
+
+// previousSignificantIndex returns the index of the last token before index i that isn't
+// whitespace or a comment, or -1 if there is none.
+func previousSignificantIndex(tokens []Token, i int) int {
+	for i--; i >= 0; i-- {
+		if !tokens[i].Kind.IsTrivia() {
+			return i
+		}
+	}
+	return -1
+}
+
+// run lexes until the end of the source, or until sync finds the old tokens can be reused
+// when it isn't nil. It returns a checkpoint for each token emitted after index mark.
+func (l *lexer) run(sync *resync, mark int) []checkpoint {
+	var checkpoints []checkpoint
+	if sync != nil {
+		sync.index = -1
+	}
+
+	for !l.atEof() {
+		if sync != nil && sync.match(l) {
+			break
+		}
+
+		start := l.checkpoint()
+		l.step()
+
+		for i := len(checkpoints) + mark; i < len(l.Tokens); i++ {
+			checkpoints = append(checkpoints, start)
+			start = noCheckpoint
+		}
+	}
+
+	return checkpoints
+}
+
+// shift moves a position that followed was so that it follows now instead. Only positions on
+// the same line as was move sideways.
+func shift(p, was, now Position) Position {
+	if p.Line == was.Line {
+		p.Column += now.Column - was.Column
+	}
+	p.Offset += now.Offset - was.Offset
+	p.Line += now.Line - was.Line
+	return p
+}
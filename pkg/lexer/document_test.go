@@ -0,0 +1,85 @@
+package lexer
+
+import (
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// documentSnippets are inserted at random places to change how the code around them lexes.
+var documentSnippets = []string{
+	"{", "}", "\"", "'", "`", "$a", "{$", "(int)", "( string )", "<<<EOT\n", "\nEOT;\n", "<<<'N'\n",
+	"/*", "*/", "/** ", "//", "#", "#[", "?>", "<?php ", "<?= ", "\n", "\r\n", " ", "enum ", "readonly",
+	"(", ":", "::", "&", "...", "yield from", "0x1F", "1_000", "fn", "__halt_compiler();", "\\Foo\\",
+}
+
+func assertDocument(t *testing.T, document *Document, options Options) {
+	t.Helper()
+
+	tokens, diagnostics := TokenizeWithOptions(document.Source(), options)
+	if !reflect.DeepEqual(document.Tokens(), tokens) {
+		for i := range tokens {
+			if i >= len(document.Tokens()) || document.Tokens()[i] != tokens[i] {
+				t.Fatalf("Relexing %q: token %d got %+v, want %+v", document.Source(), i, document.Tokens()[min(i, len(document.Tokens())-1)], tokens[i])
+			}
+		}
+		t.Fatalf("Relexing %q: got %d tokens, want %d", document.Source(), len(document.Tokens()), len(tokens))
+	}
+	if len(diagnostics) > 0 && !reflect.DeepEqual(document.Diagnostics(), diagnostics) || len(diagnostics) == 0 && len(document.Diagnostics()) > 0 {
+		t.Fatalf("Relexing %q: got diagnostics %v, want %v", document.Source(), document.Diagnostics(), diagnostics)
+	}
+}
+
+func TestDocumentApply(t *testing.T) {
+	document := NewDocument("<?php\n$a = 1;\n\necho $a;\n", Options{})
+
+	change := document.Apply(Edit{Start: 11, End: 12, Text: "42"})
+	if expected := (Change{Index: 0, Removed: 9, Inserted: 9}); change != expected {
+		t.Fatalf("got change %+v, want %+v", change, expected)
+	}
+	assertDocument(t, document, Options{})
+
+	document.Apply(Edit{Start: 6, End: 6, Text: "\"unterminated "})
+	assertDocument(t, document, Options{})
+
+	document.Apply(Edit{Start: 6, End: 20, Text: ""})
+	assertDocument(t, document, Options{})
+}
+
+func TestDocumentRandomEdits(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	options := Options{Version: PHP80}
+
+	err := filepath.WalkDir("../../tests_assets", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".php" {
+			return err
+		}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		document := NewDocument(string(source), options)
+		assertDocument(t, document, options)
+
+		for i := 0; i < 50; i++ {
+			length := len(document.Source())
+			start := random.Intn(length + 1)
+			end := min(start+random.Intn(8), length)
+			if random.Intn(2) == 0 {
+				end = start
+			}
+
+			document.Apply(Edit{Start: start, End: end, Text: documentSnippets[random.Intn(len(documentSnippets))]})
+			assertDocument(t, document, options)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,39 @@
+package lexer_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/byawitz/gint/pkg/lexer"
+)
+
+func ExampleTokenizeWithOptions() {
+	tokens, diagnostics := lexer.TokenizeWithOptions("<?php enum Suit {}", lexer.Options{Version: lexer.PHP80})
+
+	for _, token := range tokens {
+		if !token.Kind.IsTrivia() && token.Kind != lexer.TEOF {
+			fmt.Printf("%s %s %q\n", token.Start, token.Kind, token.Value)
+		}
+	}
+	fmt.Println(len(diagnostics), "problems")
+
+	// Output:
+	// 1:1 T_OPEN_TAG "<?php "
+	// 1:7 T_STRING "enum"
+	// 1:12 T_STRING "Suit"
+	// 1:17 T_OPEN_CURLY "{"
+	// 1:18 T_CLOSE_CURLY "}"
+	// 0 problems
+}
+
+func ExampleToken_json() {
+	encoded, _ := json.Marshal(lexer.Tokenize("<?php echo")[1])
+	fmt.Println(string(encoded))
+
+	var token lexer.Token
+	_ = json.Unmarshal(encoded, &token)
+	fmt.Println(token.Kind, token.Kind.IsKeyword())
+
+	// Output:
+	// {"value":"echo","kind":"T_ECHO","start":{"offset":6,"line":1,"column":7},"end":{"offset":10,"line":1,"column":11}}
+	// T_ECHO true
+}
@@ -0,0 +1,108 @@
+package lexer
+
+import (
+	"regexp"
+	"strings"
+)
+
+var heredocStart = regexp.MustCompile(`^<<<[ \t]*(?:([a-zA-Z_\x80-\xff][a-zA-Z0-9_\x80-\xff]*)|"([a-zA-Z_\x80-\xff][a-zA-Z0-9_\x80-\xff]*)"|'([a-zA-Z_\x80-\xff][a-zA-Z0-9_\x80-\xff]*)')(?:\r\n|\n|\r)`)
+
+// scanHeredocStart lexes the opening line of a heredoc or nowdoc. A nowdoc body has no
+// interpolation, so it is lexed in one go, while a heredoc body is left to scanHeredoc.
+// It returns false when the "<<<" at the current position doesn't open one.
+func (l *lexer) scanHeredocStart() bool {
+	match := heredocStart.FindStringSubmatch(l.remainder())
+	if match == nil {
+		return false
+	}
+
+	start := l.position()
+	l.emit(TStartHeredoc, len(match[0]))
+
+	if match[3] != "" {
+		l.scanNowdocBody(start, match[3])
+		return true
+	}
+
+	label := match[1] + match[2]
+	l.literals = append(l.literals, literal{start: start, label: label})
+	l.pushState(stateHeredoc)
+
+	if n := l.closingMarker(l.pos, label); n > 0 {
+		l.endHeredoc(n)
+	}
+	return true
+}
+
+func (l *lexer) scanNowdocBody(start Position, label string) {
+	for i := l.pos; l.available(i); i++ {
+		if i != l.pos && !isNewline(l.source[i-1]) {
+			continue
+		}
+
+		if n := l.closingMarker(i, label); n > 0 {
+			if i > l.pos {
+				l.emit(TEncapsedAndWhitespace, i-l.pos)
+			}
+			l.emit(TEndHeredoc, n)
+			return
+		}
+	}
+
+	l.report(start, "unterminated nowdoc")
+	l.emit(TEncapsedAndWhitespace, len(l.remainder()))
+}
+
+// scanHeredoc lexes the body of a heredoc up to the next interpolation or its closing marker.
+func (l *lexer) scanHeredoc() {
+	if l.scanInterpolation() {
+		return
+	}
+
+	label := l.literals[len(l.literals)-1].label
+	for i := l.pos; l.available(i); i++ {
+		if i == l.pos || isNewline(l.source[i-1]) {
+			if n := l.closingMarker(i, label); n > 0 {
+				if i > l.pos {
+					l.emit(TEncapsedAndWhitespace, i-l.pos)
+				}
+				l.endHeredoc(n)
+				return
+			}
+		}
+
+		if i > l.pos && l.interpolationAt(i) {
+			l.emit(TEncapsedAndWhitespace, i-l.pos)
+			return
+		}
+
+		if l.source[i] == '\\' && i+1 < len(l.source) && !isNewline(l.source[i+1]) {
+			i++
+		}
+	}
+
+	l.emit(TEncapsedAndWhitespace, len(l.remainder()))
+}
+
+func (l *lexer) endHeredoc(n int) {
+	l.emit(TEndHeredoc, n)
+	l.literals = l.literals[:len(l.literals)-1]
+	l.popState()
+}
+
+// closingMarker returns the length of the closing marker of a heredoc or nowdoc starting at
+// offset i, or 0 if there is none. Since PHP 7.3 the marker may be indented with spaces or
+// tabs, and the indentation is part of the T_END_HEREDOC token.
+func (l *lexer) closingMarker(i int, label string) int {
+	indent := l.spanAt(i, func(c byte) bool { return c == ' ' || c == '\t' })
+
+	if !strings.HasPrefix(l.source[i+indent:], label) || isLabelChar(l.byteAt(i+indent+len(label))) {
+		return 0
+	}
+
+	return indent + len(label)
+}
+
+func isNewline(c byte) bool {
+	return c == '\n' || c == '\r'
+}
@@ -0,0 +1,109 @@
+package lexer
+
+// interpolationAt reports whether an embedded variable or expression starts at offset i.
+func (l *lexer) interpolationAt(i int) bool {
+	switch l.source[i] {
+	case '$':
+		return isLabelStart(l.byteAt(i+1)) || l.byteAt(i+1) == '{'
+	case '{':
+		return l.byteAt(i+1) == '$'
+	}
+	return false
+}
+
+// scanInterpolation lexes an embedded variable or expression at the current position:
+// "$name" with an optional "[offset]" or "->property", "{$expression}" and "${expression}".
+// It returns false when there is none.
+func (l *lexer) scanInterpolation() bool {
+	if !l.interpolationAt(l.pos) {
+		return false
+	}
+
+	switch {
+	case l.at() == '{':
+		l.emit(TCurlyOpen, 1)
+		l.pushState(stateScripting)
+	case l.peek(1) == '{':
+		l.emit(TDollarOpenCurlyBraces, len("${"))
+		l.pushState(stateVarname)
+	default:
+		l.scanVariable()
+		l.scanVariableSuffix()
+	}
+
+	return true
+}
+
+// scanVariableSuffix lexes the simple array offset or property fetch that may follow an
+// embedded "$name".
+func (l *lexer) scanVariableSuffix() {
+	if l.atEof() {
+		return
+	}
+
+	switch {
+	case l.at() == '[':
+		n := l.varOffsetLength()
+		if n == 0 {
+			return
+		}
+
+		l.emit(TOpenBracket, 1)
+		switch {
+		case l.at() == '$':
+			l.scanVariable()
+		case l.at() == '-':
+			l.emit(TDash, 1)
+			l.emit(TNumString, l.span(0, isDigit))
+		case isDigit(l.at()):
+			l.emit(TNumString, l.span(0, isDigit))
+		default:
+			l.emit(TString, l.span(0, isLabelChar))
+		}
+		l.emit(TCloseBracket, 1)
+	case l.at() == '-' && l.peek(1) == '>' && isLabelStart(l.peek(2)):
+		l.emit(TObjectOperator, len("->"))
+		l.emit(TString, l.span(0, isLabelChar))
+	case l.at() == '?' && l.peek(1) == '-' && l.peek(2) == '>' && isLabelStart(l.peek(3)) && l.supports(PHP80):
+		l.emit(TNullSafeObjectOperator, len("?->"))
+		l.emit(TString, l.span(0, isLabelChar))
+	}
+}
+
+// varOffsetLength returns the length of a simple "[offset]" at the current position, where
+// the offset is a label, a variable or an optionally negative integer, or 0 if there is none.
+func (l *lexer) varOffsetLength() int {
+	n := 1
+	switch c := l.peek(1); {
+	case c == '$' && isLabelStart(l.peek(2)):
+		n = l.span(2, isLabelChar)
+	case c == '-' && isDigit(l.peek(2)):
+		n = l.span(2, isDigit)
+	case isDigit(c):
+		n = l.span(1, isDigit)
+	case isLabelStart(c):
+		n = l.span(1, isLabelChar)
+	default:
+		return 0
+	}
+
+	if l.peek(n) != ']' {
+		return 0
+	}
+	return n + 1
+}
+
+// scanVarname handles the start of a "${expression}" interpolation. A plain name followed by
+// "[" or "}" is a T_STRING_VARNAME, anything else is lexed as regular code.
+func (l *lexer) scanVarname() {
+	l.state = stateScripting
+
+	if !isLabelStart(l.at()) {
+		return
+	}
+
+	n := l.span(0, isLabelChar)
+	if next := l.peek(n); next == '[' || next == '}' {
+		l.emit(TStringVarName, n)
+	}
+}
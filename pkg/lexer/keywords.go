@@ -0,0 +1,163 @@
+package lexer
+
+import "strings"
+
+// keywords maps the reserved words of PHP, lower-cased, to their token kind.
+var keywords = map[string]Kind{
+	"eval":            TEval,
+	"new":             TNew,
+	"exit":            TExit,
+	"die":             TExit,
+	"throw":           TThrow,
+	"include_once":    TIncludeOnce,
+	"require_once":    TRequireOnce,
+	"include":         TInclude,
+	"require":         TRequire,
+	"or":              TLogicalOr,
+	"xor":             TLogicalXor,
+	"and":             TLogicalAnd,
+	"print":           TPrint,
+	"yield":           TYield,
+	"instanceof":      TInstanceof,
+	"clone":           TClone,
+	"elseif":          TElseif,
+	"else":            TElse,
+	"if":              TIf,
+	"endif":           TEndif,
+	"echo":            TEcho,
+	"do":              TDo,
+	"while":           TWhile,
+	"endwhile":        TEndWhile,
+	"for":             TFor,
+	"endfor":          TEndFor,
+	"foreach":         TForeach,
+	"endforeach":      TEndForeach,
+	"declare":         TDeclare,
+	"enddeclare":      TEndDeclare,
+	"as":              TAs,
+	"switch":          TSwitch,
+	"endswitch":       TEndSwitch,
+	"case":            TCase,
+	"default":         TDefault,
+	"match":           TMatch,
+	"break":           TBreak,
+	"continue":        TContinue,
+	"goto":            TGoto,
+	"function":        TFunction,
+	"fn":              TFn,
+	"const":           TConst,
+	"return":          TReturn,
+	"try":             TTry,
+	"catch":           TCatch,
+	"finally":         TFinally,
+	"use":             TUse,
+	"insteadof":       TInsteadof,
+	"global":          TGlobal,
+	"static":          TStatic,
+	"abstract":        TAbstract,
+	"final":           TFinal,
+	"private":         TPrivate,
+	"protected":       TProtected,
+	"public":          TPublic,
+	"readonly":        TReadonly,
+	"var":             TVar,
+	"unset":           TUnset,
+	"isset":           TIsset,
+	"empty":           TEmpty,
+	"__halt_compiler": THaltCompiler,
+	"class":           TClass,
+	"trait":           TTrait,
+	"interface":       TInterface,
+	"enum":            TEnum,
+	"extends":         TExtends,
+	"implements":      TImplements,
+	"namespace":       TNamespace,
+	"list":            TList,
+	"array":           TArray,
+	"callable":        TCallable,
+	"__line__":        TLine,
+	"__file__":        TFile,
+	"__dir__":         TDir,
+	"__class__":       TClassC,
+	"__trait__":       TTraitC,
+	"__method__":      TMethodC,
+	"__function__":    TFuncC,
+	"__property__":    TPropertyC,
+	"__namespace__":   TNsC,
+}
+
+// lookupKeyword finds the keyword kind of a label. Keywords are case-insensitive in PHP.
+func lookupKeyword(label string) (Kind, bool) {
+	for i := 0; i < len(label); i++ {
+		if label[i] >= 'A' && label[i] <= 'Z' {
+			label = strings.ToLower(label)
+			break
+		}
+	}
+
+	kind, ok := keywords[label]
+	return kind, ok
+}
+
+// contextualKeyword decides whether a keyword at the current position, n bytes long, is used
+// as a plain identifier instead. It follows what token_get_all() reports with TOKEN_PARSE:
+// member names after "->", "?->" and "::", function and constant names, and named arguments
+// are all T_STRING, as are "enum" and "readonly" when they aren't used as modifiers.
+func (l *lexer) contextualKeyword(kind Kind, n int) Kind {
+	switch l.previousSignificant(0) {
+	case TObjectOperator, TNullSafeObjectOperator, TFunction:
+		return TString
+	case TPaamayimNekudotayim:
+		if kind != TClass {
+			return TString
+		}
+	case TAmpersandFollowedByVarOrVararg, TAmpersandNotFollowedByVarOrVararg:
+		if l.previousSignificant(1) == TFunction {
+			return TString
+		}
+	case TConst:
+		if l.nextSignificant(n) == '=' {
+			return TString
+		}
+	case TOpenParen, TComma:
+		next := l.skipTrivia(l.pos + n)
+		if l.byteAt(next) == ':' && l.byteAt(next+1) != ':' {
+			return TString
+		}
+	}
+
+	switch kind {
+	case TEnum:
+		next := l.skipTrivia(l.pos + n)
+		if next == l.pos+n || !isLabelStart(l.byteAt(next)) {
+			return TString
+		}
+		word := strings.ToLower(l.source[next : next+l.spanAt(next, isLabelChar)])
+		if word == "extends" || word == "implements" {
+			return TString
+		}
+	case TReadonly:
+		if l.nextSignificant(n) == '(' {
+			return TString
+		}
+	}
+
+	return kind
+}
+
+// yieldFromLength returns the length of a "yield from" token starting at the current
+// position, or 0 if the "yield" keyword there isn't followed by "from".
+func (l *lexer) yieldFromLength() int {
+	i := l.pos + len("yield")
+	space := l.spanAt(i, isWhitespace)
+	if space == 0 || i+space+len("from") > len(l.source) {
+		return 0
+	}
+
+	i += space
+	if !strings.EqualFold(l.source[i:i+len("from")], "from") || isLabelChar(l.byteAt(i+len("from"))) {
+		return 0
+	}
+
+	return i + len("from") - l.pos
+}
@@ -0,0 +1,104 @@
+package lexer
+
+import (
+	"fmt"
+)
+
+// String returns the name php's tokenizer gives the kind, such as "T_STRING".
+func (k Kind) String() string {
+	return TokenKindString(k)
+}
+
+// MarshalText encodes the kind as its name, so it reads as "T_STRING" in JSON.
+func (k Kind) MarshalText() ([]byte, error) {
+	if k < 0 || k >= kindCount {
+		return nil, fmt.Errorf("lexer: unknown token kind %d", int(k))
+	}
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name.
+func (k *Kind) UnmarshalText(text []byte) error {
+	kind, err := ParseKind(string(text))
+	if err != nil {
+		return err
+	}
+
+	*k = kind
+	return nil
+}
+
+// kindsByName maps the name of every kind back to it.
+var kindsByName = func() map[string]Kind {
+	names := make(map[string]Kind, kindCount)
+	for kind := Kind(0); kind < kindCount; kind++ {
+		names[kind.String()] = kind
+	}
+	return names
+}()
+
+// ParseKind returns the kind with the given name, such as "T_STRING".
+func ParseKind(name string) (Kind, error) {
+	kind, ok := kindsByName[name]
+	if !ok {
+		return TEOF, fmt.Errorf("lexer: unknown token kind %q", name)
+	}
+	return kind, nil
+}
+
+// keywordKinds holds the kinds of reserved words, filled from the keywords table.
+var keywordKinds = func() map[Kind]bool {
+	kinds := map[Kind]bool{TYieldFrom: true}
+	for _, kind := range keywords {
+		kinds[kind] = true
+	}
+	return kinds
+}()
+
+var operatorKinds = map[Kind]bool{
+	TAssignment: true, TPlus: true, TDash: true, TSlash: true, TStar: true, TPercent: true, TPow: true,
+	TPlusEqual: true, TMinusEqual: true, TMulEqual: true, TDivEqual: true, TConcatEqual: true,
+	TModEqual: true, TAndEqual: true, TOrEqual: true, TXorEqual: true, TSlEqual: true, TSrEqual: true,
+	TPowEqual: true, TCoalesceEqual: true, TConcat: true, TCoalesce: true, TQuestion: true,
+	TBooleanOr: true, TBooleanAnd: true, TExclamationMark: true, TLogicalOr: true, TLogicalXor: true,
+	TLogicalAnd: true, TPipe: true, TCaret: true, TTilde: true, TSl: true, TSr: true,
+	TAmpersandNotFollowedByVarOrVararg: true, TAmpersandFollowedByVarOrVararg: true,
+	TIsEqual: true, TIsNotEqual: true, TIsIdentical: true, TIsNotIdentical: true, TSpaceship: true,
+	TIsSmaller: true, TIsGreater: true, TIsSmallerOrEqual: true, TIsGreaterOrEqual: true,
+	TInstanceof: true, TInc: true, TDec: true, TAt: true,
+}
+
+var castKinds = map[Kind]bool{
+	TIntCast: true, TDoubleCast: true, TStringCast: true, TArrayCast: true, TObjectCast: true,
+	TBoolCast: true, TUnsetCast: true,
+}
+
+// IsKeyword reports whether the kind is a reserved word, such as "function", "match" or a
+// magic constant like "__CLASS__". Words php only reserves in some places, like "enum",
+// are keywords only where the lexer gave them their keyword kind.
+func (k Kind) IsKeyword() bool {
+	return keywordKinds[k]
+}
+
+// IsOperator reports whether the kind is an operator: arithmetic, assignment, comparison,
+// logical, bitwise, string, increment, error control, "instanceof", the ternary "?" and "??".
+// Punctuation such as ";", "::" or "->" and casts aren't operators.
+func (k Kind) IsOperator() bool {
+	return operatorKinds[k]
+}
+
+// IsCast reports whether the kind is a cast such as "(int)".
+func (k Kind) IsCast() bool {
+	return castKinds[k]
+}
+
+// IsComment reports whether the kind is a comment, including doc comments.
+func (k Kind) IsComment() bool {
+	return k == TComment || k == TDocComment
+}
+
+// IsTrivia reports whether the kind is whitespace or a comment, which don't change what the
+// code means.
+func (k Kind) IsTrivia() bool {
+	return k == TWhitespace || k.IsComment()
+}
@@ -0,0 +1,76 @@
+package lexer
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestKindNames(t *testing.T) {
+	for kind := Kind(0); kind < kindCount; kind++ {
+		parsed, err := ParseKind(kind.String())
+		if err != nil || parsed != kind {
+			t.Errorf("Parsing the name of kind %d, %s: got %d, %v", int(kind), kind, int(parsed), err)
+		}
+	}
+
+	if _, err := ParseKind("T_NOT_A_TOKEN"); err == nil {
+		t.Error("Parsing an unknown name: got nil, want error")
+	}
+	if _, err := kindCount.MarshalText(); err == nil {
+		t.Error("Marshalling an unknown kind: got nil, want error")
+	}
+}
+
+func TestKindClassification(t *testing.T) {
+	cases := []struct {
+		kind                             Kind
+		keyword, operator, cast, comment bool
+	}{
+		{TFunction, true, false, false, false},
+		{TYieldFrom, true, false, false, false},
+		{TClassC, true, false, false, false},
+		{TInstanceof, true, true, false, false},
+		{TLogicalAnd, true, true, false, false},
+		{TSpaceship, false, true, false, false},
+		{TCoalesceEqual, false, true, false, false},
+		{TObjectOperator, false, false, false, false},
+		{TSemiColon, false, false, false, false},
+		{TIntCast, false, false, true, false},
+		{TDocComment, false, false, false, true},
+		{TString, false, false, false, false},
+	}
+
+	for _, c := range cases {
+		if c.kind.IsKeyword() != c.keyword || c.kind.IsOperator() != c.operator || c.kind.IsCast() != c.cast || c.kind.IsComment() != c.comment {
+			t.Errorf("Classifying %s: got keyword=%v operator=%v cast=%v comment=%v", c.kind, c.kind.IsKeyword(), c.kind.IsOperator(), c.kind.IsCast(), c.kind.IsComment())
+		}
+	}
+}
+
+func TestTokenJSON(t *testing.T) {
+	tokens := Tokenize("<?php\n$a = (int) '1'; // done\n")
+
+	encoded, err := json.Marshal(tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded []Token
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoded) != len(tokens) {
+		t.Fatalf("got %d tokens back, want %d", len(decoded), len(tokens))
+	}
+	for i := range tokens {
+		if decoded[i] != tokens[i] {
+			t.Fatalf("token %d: got %+v back, want %+v", i, decoded[i], tokens[i])
+		}
+	}
+
+	var kind Kind
+	if err := json.Unmarshal([]byte(`"T_WHATEVER"`), &kind); err == nil {
+		t.Fatal("Unmarshalling an unknown kind: got nil, want error")
+	}
+}
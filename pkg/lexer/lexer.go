@@ -2,36 +2,97 @@ package lexer
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
 )
 
 const (
 	maxErrorShowingLength = 35
 )
 
-type regexHandler func(lex *lexer, regex *regexp.Regexp)
+// state is the lexing mode, mirroring the start conditions of php's own scanner.
+type state int
 
-type regexPattern struct {
-	regex        *regexp.Regexp
-	handler      regexHandler
-	outSideOfPHP bool
+const (
+	stateInitial state = iota
+	stateScripting
+	stateHeredoc
+	stateDoubleQuotes
+	stateBackquote
+	stateVarname
+)
+
+// literal is a string, command or heredoc whose body is being lexed.
+type literal struct {
+	start Position
+	label string
 }
+
 type lexer struct {
-	patterns  []regexPattern
-	Tokens    []Token
-	source    string
-	pos       int
-	insidePHP bool
+	Tokens      []Token
+	source      string
+	pos         int
+	line        int
+	column      int
+	state       state
+	stack       []state
+	literals    []literal
+	diagnostics []Diagnostic
+	options     Options
+	halt        int
+
+	// base is the offset of source within the whole input. It is only non-zero for a Scanner,
+	// which drops the source it has already lexed.
+	base int
+	// more appends the next part of the input to source and reports whether there was any.
+	// It is only set for a Scanner, so tokens longer than what it has buffered, such as a
+	// long comment, are lexed in a single pass as the input comes in.
+	more func() bool
 }
 
+// advance moves the lexer n bytes forward, keeping track of the current line and column.
+// A "\r\n" pair counts as a single line break, as does a lone "\r" or "\n".
 func (l *lexer) advance(n int) {
-	l.pos += n
+	end := min(l.pos+n, len(l.source))
+
+	for ; l.pos < end; l.pos++ {
+		switch l.source[l.pos] {
+		case '\n':
+			l.line++
+			l.column = 1
+		case '\r':
+			if l.pos+1 < len(l.source) && l.source[l.pos+1] == '\n' {
+				l.column++
+				continue
+			}
+			l.line++
+			l.column = 1
+		default:
+			l.column++
+		}
+	}
+}
+
+func (l *lexer) position() Position {
+	return Position{Offset: l.base + l.pos, Line: l.line, Column: l.column}
 }
 
 func (l *lexer) at() byte {
 	return l.source[l.pos]
 }
 
+// peek returns the byte n positions after the current one, or 0 past the end of the source.
+func (l *lexer) peek(n int) byte {
+	return l.byteAt(l.pos + n)
+}
+
+// byteAt returns the byte at offset i, or 0 past the end of the source.
+func (l *lexer) byteAt(i int) byte {
+	if i >= len(l.source) {
+		return 0
+	}
+	return l.source[i]
+}
+
 func (l *lexer) remainder() string {
 	return l.source[l.pos:]
 }
@@ -40,294 +101,344 @@ func (l *lexer) atEof() bool {
 	return l.pos >= len(l.source)
 }
 
+// span returns how many bytes, starting n bytes after the current position, satisfy match.
+// The count includes the n skipped bytes.
+func (l *lexer) span(n int, match func(byte) bool) int {
+	return n + l.spanAt(l.pos+n, match)
+}
+
+// spanAt returns how many bytes starting at offset i satisfy match.
+func (l *lexer) spanAt(i int, match func(byte) bool) int {
+	start := i
+	for l.available(i) && match(l.source[i]) {
+		i++
+	}
+	return i - start
+}
+
+// available reports whether offset i is within the source. For a Scanner, it first reads
+// more of the input once i gets close to the end of what is buffered, leaving room for the
+// bytes the lexer looks at past i.
+func (l *lexer) available(i int) bool {
+	if l.more != nil && i+2*scannerLookahead > len(l.source) {
+		l.read(i + 2*scannerLookahead)
+	}
+	return i < len(l.source)
+}
+
+// read reads the input until the source reaches offset end or the input runs out.
+func (l *lexer) read(end int) {
+	for end > len(l.source) && l.more() {
+	}
+}
+
+// index returns the offset of the first occurrence of s at or after offset i, or -1 if
+// there is none. For a Scanner, it reads the input until it finds one or the input ends.
+func (l *lexer) index(i int, s string) int {
+	for {
+		if index := strings.Index(l.source[i:], s); index != -1 {
+			l.available(i + index)
+			return i + index
+		}
+
+		next := max(i, len(l.source)-len(s)+1)
+		if l.more == nil || !l.more() {
+			return -1
+		}
+		i = next
+	}
+}
+
+// rest returns the length of the source left to lex. For a Scanner, it reads all the input
+// first.
+func (l *lexer) rest() int {
+	if l.more != nil {
+		for l.more() {
+		}
+	}
+	return len(l.remainder())
+}
+
+// skipTrivia returns the offset of the first byte at or after i that isn't whitespace or
+// part of a comment.
+func (l *lexer) skipTrivia(i int) int {
+	for i < len(l.source) {
+		switch {
+		case isWhitespace(l.source[i]):
+			i += l.spanAt(i, isWhitespace)
+		case l.lineCommentAt(i):
+			i += l.lineCommentLength(i)
+		case strings.HasPrefix(l.source[i:], "/*"):
+			end := strings.Index(l.source[i+2:], "*/")
+			if end == -1 {
+				return len(l.source)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// nextSignificant returns the first byte that isn't trivia, n bytes after the current position.
+func (l *lexer) nextSignificant(n int) byte {
+	return l.byteAt(l.skipTrivia(l.pos + n))
+}
+
+// previousSignificant returns the kind of a token already emitted, skipping whitespace and
+// comments. With skip 0 it is the last significant token, with skip 1 the one before, and so on.
+// It returns TEOF when there is no such token.
+func (l *lexer) previousSignificant(skip int) Kind {
+	for i := len(l.Tokens) - 1; i >= 0; i-- {
+		if l.Tokens[i].Kind.IsTrivia() {
+			continue
+		}
+		if skip == 0 {
+			return l.Tokens[i].Kind
+		}
+		skip--
+	}
+	return TEOF
+}
+
+// pushState enters a new state, remembering the current one so popState can return to it.
+func (l *lexer) pushState(s state) {
+	l.stack = append(l.stack, l.state)
+	l.state = s
+}
+
+func (l *lexer) popState() {
+	if len(l.stack) == 0 {
+		return
+	}
+
+	l.state = l.stack[len(l.stack)-1]
+	l.stack = l.stack[:len(l.stack)-1]
+}
+
 func (l *lexer) push(token Token) {
 	l.Tokens = append(l.Tokens, token)
 }
 
-func (l *lexer) line() any {
-	return 3 //TODO
+// emit pushes a token that spans the next n bytes of the source and advances past them.
+func (l *lexer) emit(kind Kind, n int) {
+	token := NewToken(kind, l.source[l.pos:min(l.pos+n, len(l.source))])
+	token.Start = l.position()
+	l.advance(n)
+	token.End = l.position()
+	l.push(token)
+
+	if l.halt > 0 && !token.Kind.IsTrivia() {
+		l.halt--
+	}
 }
 
+// Tokenize splits PHP source into tokens in a single pass over its bytes.
+// The lexer holds no shared state, so it is safe to call from several goroutines.
+// It never fails: bytes it can't lex become T_BAD_CHARACTER tokens, and the problems
+// are reported by TokenizeWithDiagnostics.
 func Tokenize(source string) []Token {
+	tokens, _ := TokenizeWithDiagnostics(source)
+	return tokens
+}
+
+// TokenizeWithDiagnostics is like Tokenize but also returns the problems found in the source,
+// such as unexpected characters and unterminated strings or comments. Lexing carries on
+// after each problem, so the tokens still cover the whole source.
+func TokenizeWithDiagnostics(source string) ([]Token, []Diagnostic) {
+	return TokenizeWithOptions(source, Options{})
+}
+
+// TokenizeWithOptions is like TokenizeWithDiagnostics but lexes with the given options.
+func TokenizeWithOptions(source string, options Options) ([]Token, []Diagnostic) {
 	lex := createLexer(source)
+	lex.options = options
 
 	for !lex.atEof() {
-		matched := false
+		lex.step()
+	}
 
-		for _, pattern := range lex.patterns {
-			if lex.insidePHP && pattern.outSideOfPHP {
-				continue
-			}
-			loc := pattern.regex.FindStringIndex(lex.remainder())
+	lex.finish()
+	return lex.Tokens, lex.diagnostics
+}
 
-			if loc != nil && loc[0] == 0 {
-				pattern.handler(lex, pattern.regex)
-				matched = true
-				break
-			}
-		}
+// step lexes the token, or the few tokens, starting at the current position.
+func (l *lexer) step() {
+	if l.halt == haltReached {
+		l.emit(TInlineHtml, l.rest())
+		return
+	}
 
-		if !matched {
-			remainder := lex.remainder()
-			if len(remainder) > maxErrorShowingLength {
-				remainder = remainder[:maxErrorShowingLength] + "..."
-			}
+	switch l.state {
+	case stateInitial:
+		l.scanInlineHTML()
+	case stateScripting:
+		l.scanPHP()
+	case stateHeredoc:
+		l.scanHeredoc()
+	case stateDoubleQuotes:
+		l.scanQuoted('"', TDoubleQuote)
+	case stateBackquote:
+		l.scanQuoted('`', TBacktick)
+	case stateVarname:
+		l.scanVarname()
+	}
+}
 
-			panic(fmt.Sprintf("lexer error. unexpected token identifier near %s at line %s\n", remainder, lex.line()))
+// finish reports a string or heredoc left open at the end of the source and emits T_EOF.
+func (l *lexer) finish() {
+	if len(l.literals) > 0 {
+		unterminated := l.literals[len(l.literals)-1]
+		if unterminated.label != "" {
+			l.report(unterminated.start, "unterminated heredoc")
+		} else {
+			l.report(unterminated.start, "unterminated string")
 		}
-
 	}
 
-	lex.push(NewToken(TEOF, "EOF"))
-	return lex.Tokens
+	l.emit(TEOF, 0)
 }
 
 func createLexer(source string) *lexer {
 	return &lexer{
-		Tokens: make([]Token, 0),
+		Tokens: make([]Token, 0, len(source)/4),
 		source: source,
 		pos:    0,
-		patterns: []regexPattern{
-			{regexp.MustCompile("([\\S\\s]*?)?<\\?"), htmlHandler, true},
-			{regex: regexp.MustCompile("<\\?p?h?p?"), handler: openTagHandler},
-			{regex: regexp.MustCompile("''"), handler: staticHandler(TString, "''")},
-			{regex: regexp.MustCompile("``"), handler: staticHandler(TString, "``")},
-			{regex: regexp.MustCompile(`""`), handler: staticHandler(TString, `""`)},
-			{regex: regexp.MustCompile(`"[^"]*?"`), handler: stringHandler},
-			{regex: regexp.MustCompile("'[^']*?'"), handler: stringHandler},
-			{regex: regexp.MustCompile("`[^`]*?`"), handler: stringHandler},
-			{regex: regexp.MustCompile("<\\?="), handler: staticHandler(TOpenTagWithEcho, "<?=")},
-			{regex: regexp.MustCompile("\\?>"), handler: closeTagHandler},
-			{regex: regexp.MustCompile("\\s+"), handler: skipHandler},
-			{regex: regexp.MustCompile("/\\*+(.|[\\r\\n])*?\\*/"), handler: docCommentHandler},
-			{regex: regexp.MustCompile("/\\*[^*](.|[\\r\\n])*?\\*/"), handler: commentHandler},
-			{regex: regexp.MustCompile("//.*"), handler: commentHandler},
-			{regex: regexp.MustCompile("[0-9]+\\.[0-9]+"), handler: literalHandler(TDNumber)},
-			{regex: regexp.MustCompile("[0-9]+"), handler: literalHandler(TLNumber)},
-			{regex: regexp.MustCompile("\\$[A-z_]?[A-z_0-9]+"), handler: literalHandler(TVar)},
-			{regex: regexp.MustCompile(`;`), handler: staticHandler(TSemiColon, ";")},
-			{regex: regexp.MustCompile(`:`), handler: staticHandler(TColon, ":")},
-			{regex: regexp.MustCompile(`\?`), handler: staticHandler(TQuestion, "?")},
-			{regex: regexp.MustCompile(`\[`), handler: staticHandler(TOpenBracket, "[")},
-			{regex: regexp.MustCompile(`]`), handler: staticHandler(TCloseBracket, "]")},
-			{regex: regexp.MustCompile(`\{`), handler: staticHandler(TOPENCurly, "{")},
-			{regex: regexp.MustCompile(`}`), handler: staticHandler(TCloseCurly, "}")},
-			{regex: regexp.MustCompile(`\(`), handler: staticHandler(TOpenParen, "(")},
-			{regex: regexp.MustCompile(`\)`), handler: staticHandler(TCloseParen, ")")},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TNoElse, "},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TNameFullyQualified, "},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TNameRelative, "},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TStringVarName, "},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TNumString, "},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TWhitespace, "whitespace},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TStartHeredoc, "heredoc start},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TEndHeredoc, "heredoc end},
-			//{regex: regexp.MustCompile(""),handler: staticHandler(TBadCharacter, "invalid character},
-			{regex: regexp.MustCompile("[A-z]+\\\\[A-z]+"), handler: staticHandler(TNameQualified, "namespaced name")},
-			{regex: regexp.MustCompile("eval"), handler: staticHandler(TEval, "eval")},
-			{regex: regexp.MustCompile("new"), handler: staticHandler(TNew, "new")},
-			{regex: regexp.MustCompile("exit"), handler: staticHandler(TExit, "exit")},
-			{regex: regexp.MustCompile("throw"), handler: staticHandler(TThrow, "throw")},
-			{regex: regexp.MustCompile("include_once"), handler: staticHandler(TIncludeOnce, "include_once")},
-			{regex: regexp.MustCompile("require_once"), handler: staticHandler(TRequireOnce, "require_once")},
-			{regex: regexp.MustCompile("include"), handler: staticHandler(TInclude, "include")},
-			{regex: regexp.MustCompile("require"), handler: staticHandler(TRequire, "require")},
-			{regex: regexp.MustCompile("or"), handler: staticHandler(TLogicalOr, "or")},
-			{regex: regexp.MustCompile("xor"), handler: staticHandler(TLogicalXor, "xor")},
-			{regex: regexp.MustCompile("and"), handler: staticHandler(TLogicalAnd, "and")},
-			{regex: regexp.MustCompile("print"), handler: staticHandler(TPrint, "print")},
-			{regex: regexp.MustCompile("from"), handler: staticHandler(TYieldFrom, "yield from")},
-			{regex: regexp.MustCompile("yield"), handler: staticHandler(TYield, "yield")},
-			{regex: regexp.MustCompile("->"), handler: staticHandler(TObjectOperator, "->")},
-			{regex: regexp.MustCompile("=>"), handler: staticHandler(TDoubleArrow, "=>")},
-			{regex: regexp.MustCompile("\\+="), handler: staticHandler(TPlusEqual, "+=")},
-			{regex: regexp.MustCompile("-="), handler: staticHandler(TMinusEqual, "-=")},
-			{regex: regexp.MustCompile("\\*="), handler: staticHandler(TMulEqual, "*=")},
-			{regex: regexp.MustCompile("/="), handler: staticHandler(TDivEqual, "/=")},
-			{regex: regexp.MustCompile("\\.="), handler: staticHandler(TConcatEqual, ".=")},
-			{regex: regexp.MustCompile("\\."), handler: staticHandler(TConcat, ".")},
-			{regex: regexp.MustCompile(","), handler: staticHandler(TComma, ",")},
-			{regex: regexp.MustCompile("%="), handler: staticHandler(TModEqual, "%=")},
-			{regex: regexp.MustCompile("&="), handler: staticHandler(TAndEqual, "&=")},
-			{regex: regexp.MustCompile("\\|="), handler: staticHandler(TOrEqual, "|=")},
-			{regex: regexp.MustCompile("\\^="), handler: staticHandler(TXorEqual, "^=")},
-			{regex: regexp.MustCompile("<<="), handler: staticHandler(TSlEqual, "<<=")},
-			{regex: regexp.MustCompile(">>="), handler: staticHandler(TSrEqual, ">>=")},
-			{regex: regexp.MustCompile("\\*\\*="), handler: staticHandler(TPowEqual, "**=")},
-			{regex: regexp.MustCompile("\\?\\?="), handler: staticHandler(TCoalesceEqual, "??=")},
-			{regex: regexp.MustCompile("\\?\\?"), handler: staticHandler(TCoalesce, "??")},
-			{regex: regexp.MustCompile("\\|\\|"), handler: staticHandler(TBooleanOr, "||")},
-			{regex: regexp.MustCompile("@"), handler: staticHandler(TBooleanOr, "@")},
-			{regex: regexp.MustCompile("\\|"), handler: staticHandler(TPipe, "|")},
-			{regex: regexp.MustCompile("&&"), handler: staticHandler(TBooleanAnd, "&&")},
-			{regex: regexp.MustCompile("amp"), handler: staticHandler(TAmpersandNotFollowedByVarOrVararg, "amp")},
-			{regex: regexp.MustCompile("&"), handler: staticHandler(TAmpersandFollowedByVarOrVararg, "&")},
-			{regex: regexp.MustCompile("=="), handler: staticHandler(TIsEqual, "==")},
-			{regex: regexp.MustCompile("!="), handler: staticHandler(TIsNotEqual, "!=")},
-			{regex: regexp.MustCompile("!"), handler: staticHandler(TNotEqual, "!")},
-			{regex: regexp.MustCompile("==="), handler: staticHandler(TIsIdentical, "===")},
-			{regex: regexp.MustCompile("!=="), handler: staticHandler(TIsNotIdentical, "!==")},
-			{regex: regexp.MustCompile("<=>"), handler: staticHandler(TSpaceship, "<=>")},
-			{regex: regexp.MustCompile("<="), handler: staticHandler(TIsSmallerOrEqual, "<=")},
-			{regex: regexp.MustCompile(">="), handler: staticHandler(TIsGreaterOrEqual, ">=")},
-			{regex: regexp.MustCompile("<"), handler: staticHandler(TIsSmaller, "<")},
-			{regex: regexp.MustCompile(">"), handler: staticHandler(TIsGreater, ">")},
-			{regex: regexp.MustCompile("<<"), handler: staticHandler(TSl, "<<")},
-			{regex: regexp.MustCompile(">>"), handler: staticHandler(TSr, ">>")},
-			{regex: regexp.MustCompile(`=`), handler: staticHandler(TAssignment, "=")},
-			{regex: regexp.MustCompile(`\+`), handler: staticHandler(TPlus, "+")},
-			{regex: regexp.MustCompile(`-`), handler: staticHandler(TDash, "-")},
-			{regex: regexp.MustCompile(`/`), handler: staticHandler(TSlash, "/")},
-			{regex: regexp.MustCompile(`\*`), handler: staticHandler(TStar, "*")},
-			{regex: regexp.MustCompile(`%`), handler: staticHandler(TPercent, "%")},
-			{regex: regexp.MustCompile("instanceof"), handler: staticHandler(TInstanceof, "instanceof")},
-			{regex: regexp.MustCompile("(int)"), handler: staticHandler(TIntCast, "(int)")},
-			{regex: regexp.MustCompile("(double)"), handler: staticHandler(TDoubleCast, "(double)")},
-			{regex: regexp.MustCompile("(string)"), handler: staticHandler(TStringCast, "(string)")},
-			{regex: regexp.MustCompile("(array)"), handler: staticHandler(TArrayCast, "(array)")},
-			{regex: regexp.MustCompile("(object)"), handler: staticHandler(TObjectCast, "(object)")},
-			{regex: regexp.MustCompile("(bool)"), handler: staticHandler(TBoolCast, "(bool)")},
-			{regex: regexp.MustCompile("(unset)"), handler: staticHandler(TUnsetCast, "(unset)")},
-			{regex: regexp.MustCompile("\\*\\*"), handler: staticHandler(TPow, "**")},
-			{regex: regexp.MustCompile("clone"), handler: staticHandler(TClone, "clone")},
-			{regex: regexp.MustCompile("elseif"), handler: staticHandler(TElseif, "elseif")},
-			{regex: regexp.MustCompile("else"), handler: staticHandler(TElse, "else")},
-			{regex: regexp.MustCompile("if"), handler: staticHandler(TIf, "if")},
-			{regex: regexp.MustCompile("endif"), handler: staticHandler(TEndif, "endif")},
-			{regex: regexp.MustCompile("echo"), handler: staticHandler(TEcho, "echo")},
-			{regex: regexp.MustCompile("do"), handler: staticHandler(TDo, "do")},
-			{regex: regexp.MustCompile("while"), handler: staticHandler(TWhile, "while")},
-			{regex: regexp.MustCompile("endwhile"), handler: staticHandler(TEndWhile, "endwhile")},
-			{regex: regexp.MustCompile("for"), handler: staticHandler(TFor, "for")},
-			{regex: regexp.MustCompile("endfor"), handler: staticHandler(TEndFor, "endfor")},
-			{regex: regexp.MustCompile("foreach"), handler: staticHandler(TForeach, "foreach")},
-			{regex: regexp.MustCompile("endforeach"), handler: staticHandler(TEndForeach, "endforeach")},
-			{regex: regexp.MustCompile("declare"), handler: staticHandler(TDeclare, "declare")},
-			{regex: regexp.MustCompile("enddeclare"), handler: staticHandler(TEndDeclare, "enddeclare")},
-			{regex: regexp.MustCompile("as"), handler: staticHandler(TAs, "as")},
-			{regex: regexp.MustCompile("switch"), handler: staticHandler(TSwitch, "switch")},
-			{regex: regexp.MustCompile("endswitch"), handler: staticHandler(TEndSwitch, "endswitch")},
-			{regex: regexp.MustCompile("case"), handler: staticHandler(TCase, "case")},
-			{regex: regexp.MustCompile("default"), handler: staticHandler(TDefault, "default")},
-			{regex: regexp.MustCompile("match"), handler: staticHandler(TMatch, "match")},
-			{regex: regexp.MustCompile("break"), handler: staticHandler(TBreak, "break")},
-			{regex: regexp.MustCompile("continue"), handler: staticHandler(TContinue, "continue")},
-			{regex: regexp.MustCompile("goto"), handler: staticHandler(TGoto, "goto")},
-			{regex: regexp.MustCompile("function"), handler: staticHandler(TFunction, "function")},
-			{regex: regexp.MustCompile("fn"), handler: staticHandler(TFn, "fn")},
-			{regex: regexp.MustCompile("const"), handler: staticHandler(TConst, "const")},
-			{regex: regexp.MustCompile("return"), handler: staticHandler(TReturn, "return")},
-			{regex: regexp.MustCompile("try"), handler: staticHandler(TTry, "try")},
-			{regex: regexp.MustCompile("catch"), handler: staticHandler(TCatch, "catch")},
-			{regex: regexp.MustCompile("finally"), handler: staticHandler(TFinally, "finally")},
-			{regex: regexp.MustCompile("use"), handler: staticHandler(TUse, "use")},
-			{regex: regexp.MustCompile("insteadof"), handler: staticHandler(TInsteadof, "insteadof")},
-			{regex: regexp.MustCompile("global"), handler: staticHandler(TGlobal, "global")},
-			{regex: regexp.MustCompile("static"), handler: staticHandler(TStatic, "static")},
-			{regex: regexp.MustCompile("abstract"), handler: staticHandler(TAbstract, "abstract")},
-			{regex: regexp.MustCompile("final"), handler: staticHandler(TFinal, "final")},
-			{regex: regexp.MustCompile("private"), handler: staticHandler(TPrivate, "private")},
-			{regex: regexp.MustCompile("protected"), handler: staticHandler(TProtected, "protected")},
-			{regex: regexp.MustCompile("public"), handler: staticHandler(TPublic, "public")},
-			{regex: regexp.MustCompile("readonly"), handler: staticHandler(TReadonly, "readonly")},
-			{regex: regexp.MustCompile("var"), handler: staticHandler(TVar, "var")},
-			{regex: regexp.MustCompile("unset"), handler: staticHandler(TUnset, "unset")},
-			{regex: regexp.MustCompile("isset"), handler: staticHandler(TIsset, "isset")},
-			{regex: regexp.MustCompile("empty"), handler: staticHandler(TEmpty, "empty")},
-			{regex: regexp.MustCompile("__halt_compiler"), handler: staticHandler(THaltCompiler, "__halt_compiler")},
-			{regex: regexp.MustCompile("class"), handler: staticHandler(TClass, "class")},
-			{regex: regexp.MustCompile("trait"), handler: staticHandler(TTrait, "trait")},
-			{regex: regexp.MustCompile("interface"), handler: staticHandler(TInterface, "interface")},
-			{regex: regexp.MustCompile("enum"), handler: staticHandler(TEnum, "enum")},
-			{regex: regexp.MustCompile("extends"), handler: staticHandler(TExtends, "extends")},
-			{regex: regexp.MustCompile("implements"), handler: staticHandler(TImplements, "implements")},
-			{regex: regexp.MustCompile("namespace"), handler: staticHandler(TNamespace, "namespace")},
-			{regex: regexp.MustCompile("list"), handler: staticHandler(TList, "list")},
-			{regex: regexp.MustCompile("array"), handler: staticHandler(TArray, "array")},
-			{regex: regexp.MustCompile("callable"), handler: staticHandler(TCallable, "callable")},
-			{regex: regexp.MustCompile("__LINE__"), handler: staticHandler(TLine, "__LINE__")},
-			{regex: regexp.MustCompile("__FILE__"), handler: staticHandler(TFile, "__FILE__")},
-			{regex: regexp.MustCompile("__DIR__"), handler: staticHandler(TDir, "__DIR__")},
-			{regex: regexp.MustCompile("__CLASS__"), handler: staticHandler(TClassC, "__CLASS__")},
-			{regex: regexp.MustCompile("__TRAIT__"), handler: staticHandler(TTraitC, "__TRAIT__")},
-			{regex: regexp.MustCompile("__METHOD__"), handler: staticHandler(TMethodC, "__METHOD__")},
-			{regex: regexp.MustCompile("__FUNCTION__"), handler: staticHandler(TFuncC, "__FUNCTION__")},
-			{regex: regexp.MustCompile("__PROPERTY__"), handler: staticHandler(TPropertyC, "__PROPERTY__")},
-			{regex: regexp.MustCompile("__NAMESPACE__"), handler: staticHandler(TNsC, "__NAMESPACE__")},
-			{regex: regexp.MustCompile("#\\["), handler: staticHandler(TAttribute, "#[")},
-			{regex: regexp.MustCompile("\\+\\+"), handler: staticHandler(TInc, "++")},
-			{regex: regexp.MustCompile("--"), handler: staticHandler(TDec, "--")},
-			{regex: regexp.MustCompile("\\?->"), handler: staticHandler(TNullSafeObjectOperator, "?->")},
-			{regex: regexp.MustCompile("\\${"), handler: staticHandler(TDollarOpenCurlyBraces, "${")},
-			{regex: regexp.MustCompile("{\\$"), handler: staticHandler(TCurlyOpen, "{$")},
-			{regex: regexp.MustCompile("::"), handler: staticHandler(TPaamayimNekudotayim, "::")},
-			{regex: regexp.MustCompile("\\\\"), handler: staticHandler(TNsSeparator, "\\\\")},
-			{regex: regexp.MustCompile("\\.\\.\\."), handler: staticHandler(TEllipsis, "...")},
-			{regex: regexp.MustCompile("[a-zA-Z_\\x80-\\xff][a-zA-Z0-9_\\x80-\\xff]*"), handler: literalHandler(TString)},
-		},
+		line:   1,
+		column: 1,
+		halt:   -1,
 	}
 }
 
-func staticHandler(kind Kind, value string) regexHandler {
-	return func(lex *lexer, regex *regexp.Regexp) {
-		lex.advance(len(value))
-		lex.push(NewToken(kind, value))
+func (l *lexer) scanPHP() {
+	c := l.at()
+
+	switch {
+	case isWhitespace(c):
+		l.emit(TWhitespace, l.span(0, isWhitespace))
+	case c == '$' && isLabelStart(l.peek(1)):
+		l.scanVariable()
+	case isDigit(c) || c == '.' && isDigit(l.peek(1)):
+		l.scanNumber()
+	case isLabelStart(c):
+		l.scanIdentifier()
+	case c == '\\' && isLabelStart(l.peek(1)) && l.supports(PHP80):
+		l.emit(TNameFullyQualified, l.nameLength(1))
+	case c == '\'':
+		l.scanSingleQuoted()
+	case c == '"':
+		l.scanDoubleQuoted()
+	case c == '`':
+		l.enterQuoted(TBacktick, stateBackquote)
+	case c == '/' && l.peek(1) == '*':
+		l.scanBlockComment()
+	case l.lineCommentAt(l.pos):
+		l.emit(TComment, l.lineCommentLength(l.pos))
+	case c == '?' && l.peek(1) == '>':
+		l.emit(TCloseTag, l.closeTagLength())
+		l.state = stateInitial
+	case c == '<' && strings.HasPrefix(l.remainder(), "<<<"):
+		if !l.scanHeredocStart() {
+			l.emit(TSl, len("<<"))
+		}
+	case c == '(':
+		if kind, n := l.castLength(); n > 0 {
+			l.emit(kind, n)
+		} else {
+			l.emit(TOpenParen, 1)
+		}
+	case c == '&' && l.peek(1) != '&' && l.peek(1) != '=':
+		l.emit(l.ampersandKind(), 1)
+	case c == '{':
+		l.emit(TOPENCurly, 1)
+		l.pushState(stateScripting)
+	case c == '}':
+		l.emit(TCloseCurly, 1)
+		l.popState()
+	default:
+		op, ok := matchOperator(l.remainder())
+		if ok && op.kind == TNullSafeObjectOperator && !l.supports(PHP80) {
+			op = operator{"?", TQuestion}
+		}
+
+		if !ok {
+			l.report(l.position(), fmt.Sprintf("unexpected character %q", c))
+			l.emit(TBadCharacter, 1)
+			return
+		}
+		l.emit(op.kind, len(op.value))
 	}
 }
-func htmlHandler(lex *lexer, regex *regexp.Regexp) {
-	if lex.insidePHP {
+
+func (l *lexer) scanVariable() {
+	l.emit(TVariable, l.span(1, isLabelChar))
+}
+
+// scanIdentifier lexes a keyword, a plain label or a PHP 8 name token: a qualified name
+// such as Foo\Bar or a relative one such as namespace\Foo. Before PHP 8.0 names are lexed
+// segment by segment, with T_NS_SEPARATOR tokens in between.
+func (l *lexer) scanIdentifier() {
+	n := l.span(0, isLabelChar)
+
+	if l.peek(n) == '\\' && isLabelStart(l.peek(n+1)) && l.supports(PHP80) {
+		if strings.EqualFold(l.source[l.pos:l.pos+n], "namespace") {
+			l.emit(TNameRelative, l.nameLength(n+1))
+		} else {
+			l.emit(TNameQualified, l.nameLength(n+1))
+		}
 		return
 	}
 
-	html := regex.FindStringSubmatch(lex.remainder())
-	if len(html) != 2 {
+	kind, ok := lookupKeyword(l.source[l.pos : l.pos+n])
+	if since, reserved := keywordVersions[kind]; reserved && !l.supports(since) {
+		ok = false
+	}
+
+	if !ok {
+		l.emit(TString, n)
 		return
 	}
 
-	lex.push(NewToken(TInlineHtml, html[1]))
-	lex.advance(len(html[1]))
-	lex.insidePHP = true
-}
-func openTagHandler(lex *lexer, regex *regexp.Regexp) {
-	tag := regex.FindString(lex.remainder())
-	lex.push(NewToken(TOpenTag, tag))
-	lex.advance(len(tag))
-}
-func closeTagHandler(lex *lexer, regex *regexp.Regexp) {
-	tag := regex.FindString(lex.remainder())
-	lex.push(NewToken(TCloseTag, tag))
-	lex.advance(len(tag))
-	lex.insidePHP = false
-}
-func skipHandler(lex *lexer, regex *regexp.Regexp) {
-	tag := regex.FindString(lex.remainder())
-	lex.advance(len(tag))
+	kind = l.contextualKeyword(kind, n)
+	if kind == THaltCompiler {
+		l.emit(kind, n)
+		l.halt = haltTokens
+		return
+	}
+
+	if kind == TYield {
+		if length := l.yieldFromLength(); length > 0 {
+			l.emit(TYieldFrom, length)
+			return
+		}
+	}
+
+	l.emit(kind, n)
 }
-func docCommentHandler(lex *lexer, regex *regexp.Regexp) {
 
-	docComment := regex.FindStringSubmatch(lex.remainder())
-	if len(docComment) != 2 {
-		return
+// nameLength returns the length of a name whose segments, separated by backslashes, start
+// n bytes after the current position. The count includes the n skipped bytes.
+func (l *lexer) nameLength(n int) int {
+	n = l.span(n, isLabelChar)
+	for l.peek(n) == '\\' && isLabelStart(l.peek(n+1)) {
+		n = l.span(n+1, isLabelChar)
 	}
+	return n
+}
 
-	lex.push(NewToken(TDocComment, docComment[0]))
-	lex.advance(len(docComment[0]))
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
 }
-func commentHandler(lex *lexer, regex *regexp.Regexp) {
-	comment := regex.FindString(lex.remainder())
 
-	lex.push(NewToken(TComment, comment))
-	lex.advance(len(comment))
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
 }
-func stringHandler(lex *lexer, regex *regexp.Regexp) {
-	match := regex.FindString(lex.remainder())
-	lex.push(NewToken(TString, match))
-	lex.advance(len(match))
+
+func isLabelStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c >= 0x80
 }
-func literalHandler(kind Kind) regexHandler {
-	return func(lex *lexer, regex *regexp.Regexp) {
-		match := regex.FindString(lex.remainder())
-		lex.push(NewToken(kind, match))
-		lex.advance(len(match))
-	}
+
+func isLabelChar(c byte) bool {
+	return isLabelStart(c) || isDigit(c)
 }
@@ -0,0 +1,145 @@
+package lexer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const benchmarkClass = `
+/**
+ * Handles the %[1]d users of the application.
+ */
+class UserController%[1]d extends Controller implements HasMiddleware
+{
+    public function __construct(private UserRepository $users)
+    {
+        $this->middleware = ['auth', 'verified'];
+    }
+
+    public function index(Request $request): Response
+    {
+        $page = (int) $request->get('page', 1);
+        $users = $this->users->paginate($page * 15, 15.5);
+
+        foreach ($users as $key => $user) {
+            if ($user->isAdmin() && $key >= 10) {
+                continue;
+            }
+            // Format the name before rendering it
+            $user->name = ucfirst($user->name) . " (" . $user->email . ")";
+        }
+
+        return view('users.index', compact('users'));
+    }
+}
+`
+
+func largePHPFile(classes int) string {
+	var builder strings.Builder
+	builder.WriteString("<?php\n\nnamespace App\\Http\\Controllers;\n")
+
+	for i := 0; i < classes; i++ {
+		builder.WriteString(fmt.Sprintf(benchmarkClass, i))
+	}
+
+	return builder.String()
+}
+
+// longTokenSources returns sources of about size bytes made of a single long token each,
+// which a Scanner can't lex from what it has buffered.
+func longTokenSources(size int) map[string]string {
+	repeat := func(s string) string {
+		return strings.Repeat(s, size/len(s))
+	}
+
+	return map[string]string{
+		"inline HTML":  repeat("<p>Hello <?xml</p>\r\n") + "<?php echo 1;",
+		"comment":      "<?php /* " + repeat("a comment\r\n") + "*/ $a;",
+		"line comment": "<?php // " + repeat("a comment ") + "\n$a;",
+		"whitespace":   "<?php " + repeat(" \t\r\n") + "$a;",
+		"string":       "<?php $a = '" + repeat("it\\'s ") + "';",
+		"string body":  "<?php $a = \"" + repeat("it's \\\" ") + "$b\";",
+		"heredoc":      "<?php <<<EOT\n" + repeat("a line\r\n") + "EOT;\n",
+		"nowdoc":       "<?php <<<'EOT'\n" + repeat("a line\n") + "EOT;\n",
+		"halt data":    "<?php __halt_compiler();" + repeat("\x00data\r"),
+	}
+}
+
+func BenchmarkScanLongTokens(b *testing.B) {
+	for name, source := range longTokenSources(5 * 1024 * 1024) {
+		b.Run(name+"/Tokenize", func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for i := 0; i < b.N; i++ {
+				Tokenize(source)
+			}
+		})
+
+		b.Run(name+"/Scanner", func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for i := 0; i < b.N; i++ {
+				scanner := NewScanner(strings.NewReader(source), Options{})
+				for scanner.Next().Kind != TEOF {
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTokenizeLargeFile(b *testing.B) {
+	source := largePHPFile(2000)
+
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Tokenize(source)
+	}
+}
+
+func BenchmarkTokenizeParallel(b *testing.B) {
+	source := largePHPFile(200)
+
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Tokenize(source)
+		}
+	})
+}
+
+func BenchmarkTokenizeAllTokens(b *testing.B) {
+	file, err := os.ReadFile("../../tests_assets/lexer/all_tokens.php")
+	if err != nil {
+		b.Fatal("error opening PHP file for benchmarking")
+	}
+	source := string(file)
+
+	b.SetBytes(int64(len(source)))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		Tokenize(source)
+	}
+}
+
+func TestTokenizeConcurrently(t *testing.T) {
+	source := largePHPFile(20)
+	expected := len(Tokenize(source))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := len(Tokenize(source)); got != expected {
+				t.Errorf("expected %d tokens, got %d", expected, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,7 +1,11 @@
 package lexer
 
 import (
+	"io/fs"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -16,3 +20,719 @@ func TestParsing(t *testing.T) {
 		t.Fatal("no tokens found")
 	}
 }
+
+func TestPositions(t *testing.T) {
+	source := "<h1>\n<?php\n$a = 10;\r\n  echo $a;"
+
+	tokens := Tokenize(source)
+
+	expected := []struct {
+		kind  Kind
+		start Position
+		end   Position
+	}{
+		{TInlineHtml, Position{0, 1, 1}, Position{5, 2, 1}},
+		{TOpenTag, Position{5, 2, 1}, Position{11, 3, 1}},
+		{TVariable, Position{11, 3, 1}, Position{13, 3, 3}},
+		{TWhitespace, Position{13, 3, 3}, Position{14, 3, 4}},
+		{TAssignment, Position{14, 3, 4}, Position{15, 3, 5}},
+		{TWhitespace, Position{15, 3, 5}, Position{16, 3, 6}},
+		{TLNumber, Position{16, 3, 6}, Position{18, 3, 8}},
+		{TSemiColon, Position{18, 3, 8}, Position{19, 3, 9}},
+		{TWhitespace, Position{19, 3, 9}, Position{23, 4, 3}},
+		{TEcho, Position{23, 4, 3}, Position{27, 4, 7}},
+		{TWhitespace, Position{27, 4, 7}, Position{28, 4, 8}},
+		{TVariable, Position{28, 4, 8}, Position{30, 4, 10}},
+		{TSemiColon, Position{30, 4, 10}, Position{31, 4, 11}},
+		{TEOF, Position{31, 4, 11}, Position{31, 4, 11}},
+	}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(tokens))
+	}
+
+	for i, want := range expected {
+		got := tokens[i]
+		if got.Kind != want.kind || got.Start != want.start || got.End != want.end {
+			t.Errorf("token %d: expected %s %v-%v, got %s %v-%v", i, TokenKindString(want.kind), want.start, want.end, TokenKindString(got.Kind), got.Start, got.End)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	err := filepath.WalkDir("../../tests_assets", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".php" {
+			return err
+		}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var joined strings.Builder
+		for _, token := range Tokenize(string(source)) {
+			joined.WriteString(token.Value)
+		}
+
+		if joined.String() != string(source) {
+			t.Errorf("joining the tokens of %s doesn't reproduce the source, got:\n%s", path, joined.String())
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// significantKinds returns the kinds of every token of source except whitespace, the open tag and EOF.
+func significantKinds(source string) []Kind {
+	var kinds []Kind
+
+	for _, token := range Tokenize(source) {
+		if token.isOneOfMany(TWhitespace, TOpenTag, TEOF) {
+			continue
+		}
+		kinds = append(kinds, token.Kind)
+	}
+
+	return kinds
+}
+
+func assertKinds(t *testing.T, source string, expected ...Kind) {
+	t.Helper()
+
+	got := significantKinds(source)
+	if !slices.Equal(got, expected) {
+		t.Errorf("lexing %q\nexpected %v\ngot      %v", source, kindNames(expected), kindNames(got))
+	}
+}
+
+func kindNames(kinds []Kind) []string {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = TokenKindString(kind)
+	}
+	return names
+}
+
+func TestOperatorsLongestMatch(t *testing.T) {
+	assertKinds(t, "<?php $a === $b;", TVariable, TIsIdentical, TVariable, TSemiColon)
+	assertKinds(t, "<?php $a <=> $b;", TVariable, TSpaceship, TVariable, TSemiColon)
+	assertKinds(t, "<?php $a <<= 2;", TVariable, TSlEqual, TLNumber, TSemiColon)
+	assertKinds(t, "<?php $a?->b;", TVariable, TNullSafeObjectOperator, TString, TSemiColon)
+	assertKinds(t, "<?php $a **= 1.5;", TVariable, TPowEqual, TDNumber, TSemiColon)
+}
+
+func TestScanner(t *testing.T) {
+	assertKinds(t, "<?php echo 'it\\'s', \"a \\\"b\\\"\";", TEcho, TConstantEncapsedString, TComma, TConstantEncapsedString, TSemiColon)
+	assertKinds(t, "<?php /** doc */ /* block */ // line", TDocComment, TComment, TComment)
+	assertKinds(t, "<?php new App\\Models\\User;", TNew, TNameQualified, TSemiColon)
+	assertKinds(t, "<html><?php ?></html>", TInlineHtml, TCloseTag, TInlineHtml)
+}
+
+func TestKeywordBoundaries(t *testing.T) {
+	assertKinds(t, "<?php $order = format($foreach);", TVariable, TAssignment, TString, TOpenParen, TVariable, TCloseParen, TSemiColon)
+	assertKinds(t, "<?php foreach ($a as $b) endforeach;", TForeach, TOpenParen, TVariable, TAs, TVariable, TCloseParen, TEndForeach, TSemiColon)
+	assertKinds(t, "<?php FOREACH ($a AS $b); NEW Foo; __line__;", TForeach, TOpenParen, TVariable, TAs, TVariable, TCloseParen, TSemiColon, TNew, TString, TSemiColon, TLine, TSemiColon)
+	assertKinds(t, "<?php die();", TExit, TOpenParen, TCloseParen, TSemiColon)
+}
+
+func TestContextualKeywords(t *testing.T) {
+	assertKinds(t, "<?php $obj->class; $obj?->list();", TVariable, TObjectOperator, TString, TSemiColon, TVariable, TNullSafeObjectOperator, TString, TOpenParen, TCloseParen, TSemiColon)
+	assertKinds(t, "<?php Foo::new(); Foo::class;", TString, TPaamayimNekudotayim, TString, TOpenParen, TCloseParen, TSemiColon, TString, TPaamayimNekudotayim, TClass, TSemiColon)
+	assertKinds(t, "<?php function match() {} function &readonly() {}", TFunction, TString, TOpenParen, TCloseParen, TOPENCurly, TCloseCurly, TFunction, TAmpersandNotFollowedByVarOrVararg, TString, TOpenParen, TCloseParen, TOPENCurly, TCloseCurly)
+	assertKinds(t, "<?php const LIST = 1;", TConst, TString, TAssignment, TLNumber, TSemiColon)
+	assertKinds(t, "<?php foo(array: 1, default: 2);", TString, TOpenParen, TString, TColon, TLNumber, TComma, TString, TColon, TLNumber, TCloseParen, TSemiColon)
+}
+
+func TestEnumAndReadonly(t *testing.T) {
+	assertKinds(t, "<?php enum Suit {}", TEnum, TString, TOPENCurly, TCloseCurly)
+	assertKinds(t, "<?php class enum extends Foo {}", TClass, TString, TExtends, TString, TOPENCurly, TCloseCurly)
+	assertKinds(t, "<?php enum(1);", TString, TOpenParen, TLNumber, TCloseParen, TSemiColon)
+	assertKinds(t, "<?php readonly class A {}", TReadonly, TClass, TString, TOPENCurly, TCloseCurly)
+	assertKinds(t, "<?php readonly($a);", TString, TOpenParen, TVariable, TCloseParen, TSemiColon)
+}
+
+func TestYieldFrom(t *testing.T) {
+	assertKinds(t, "<?php yield from gen(); yield $a; yield fromage;", TYieldFrom, TString, TOpenParen, TCloseParen, TSemiColon, TYield, TVariable, TSemiColon, TYield, TString, TSemiColon)
+
+	tokens := Tokenize("<?php yield\n  FROM $a;")
+	if tokens[1].Kind != TYieldFrom || tokens[1].Value != "yield\n  FROM" {
+		t.Errorf("expected a single T_YIELD_FROM token, got %s %q", TokenKindString(tokens[1].Kind), tokens[1].Value)
+	}
+}
+
+type tokenValue struct {
+	kind  Kind
+	value string
+}
+
+func assertTokens(t *testing.T, source string, expected ...tokenValue) {
+	t.Helper()
+
+	var got []tokenValue
+	for _, token := range Tokenize(source) {
+		if token.isOneOfMany(TWhitespace, TOpenTag, TEOF) {
+			continue
+		}
+		got = append(got, tokenValue{token.Kind, token.Value})
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Errorf("lexing %q\nexpected %v\ngot      %v", source, expected, got)
+	}
+}
+
+func TestHeredoc(t *testing.T) {
+	assertTokens(t, "<?php $sql = <<<SQL\nSELECT * FROM users\nSQL;",
+		tokenValue{TVariable, "$sql"},
+		tokenValue{TAssignment, "="},
+		tokenValue{TStartHeredoc, "<<<SQL\n"},
+		tokenValue{TEncapsedAndWhitespace, "SELECT * FROM users\n"},
+		tokenValue{TEndHeredoc, "SQL"},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, "<?php <<<\"EOT\"\n    Hi $name, {$user->mail} ${greeting} \\$escaped $list[0] $obj->prop\n    EOT;",
+		tokenValue{TStartHeredoc, "<<<\"EOT\"\n"},
+		tokenValue{TEncapsedAndWhitespace, "    Hi "},
+		tokenValue{TVariable, "$name"},
+		tokenValue{TEncapsedAndWhitespace, ", "},
+		tokenValue{TCurlyOpen, "{"},
+		tokenValue{TVariable, "$user"},
+		tokenValue{TObjectOperator, "->"},
+		tokenValue{TString, "mail"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TEncapsedAndWhitespace, " "},
+		tokenValue{TDollarOpenCurlyBraces, "${"},
+		tokenValue{TStringVarName, "greeting"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TEncapsedAndWhitespace, " \\$escaped "},
+		tokenValue{TVariable, "$list"},
+		tokenValue{TOpenBracket, "["},
+		tokenValue{TNumString, "0"},
+		tokenValue{TCloseBracket, "]"},
+		tokenValue{TEncapsedAndWhitespace, " "},
+		tokenValue{TVariable, "$obj"},
+		tokenValue{TObjectOperator, "->"},
+		tokenValue{TString, "prop"},
+		tokenValue{TEncapsedAndWhitespace, "\n"},
+		tokenValue{TEndHeredoc, "    EOT"},
+		tokenValue{TSemiColon, ";"},
+	)
+}
+
+func TestNowdoc(t *testing.T) {
+	assertTokens(t, "<?php <<<'MAIL'\n  Dear $name,\n  MAILING\n  MAIL\n;",
+		tokenValue{TStartHeredoc, "<<<'MAIL'\n"},
+		tokenValue{TEncapsedAndWhitespace, "  Dear $name,\n  MAILING\n"},
+		tokenValue{TEndHeredoc, "  MAIL"},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, "<?php foo(<<<EOT\nEOT, 1 << 2);",
+		tokenValue{TString, "foo"},
+		tokenValue{TOpenParen, "("},
+		tokenValue{TStartHeredoc, "<<<EOT\n"},
+		tokenValue{TEndHeredoc, "EOT"},
+		tokenValue{TComma, ","},
+		tokenValue{TLNumber, "1"},
+		tokenValue{TSl, "<<"},
+		tokenValue{TLNumber, "2"},
+		tokenValue{TCloseParen, ")"},
+		tokenValue{TSemiColon, ";"},
+	)
+}
+
+func TestNestedHeredoc(t *testing.T) {
+	assertKinds(t, "<?php <<<A\n{$a(<<<B\nb\nB)}\nA;",
+		TStartHeredoc, TCurlyOpen, TVariable, TOpenParen, TStartHeredoc, TEncapsedAndWhitespace, TEndHeredoc, TCloseParen, TCloseCurly, TEncapsedAndWhitespace, TEndHeredoc, TSemiColon)
+}
+
+func TestDoubleQuotedStrings(t *testing.T) {
+	assertTokens(t, `<?php "say \"hi\"" . 'it\'s' . "";`,
+		tokenValue{TConstantEncapsedString, `"say \"hi\""`},
+		tokenValue{TConcat, "."},
+		tokenValue{TConstantEncapsedString, `'it\'s'`},
+		tokenValue{TConcat, "."},
+		tokenValue{TConstantEncapsedString, `""`},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, `<?php "Hello $name[key], {$a['b']} \$x ${c}!";`,
+		tokenValue{TDoubleQuote, `"`},
+		tokenValue{TEncapsedAndWhitespace, "Hello "},
+		tokenValue{TVariable, "$name"},
+		tokenValue{TOpenBracket, "["},
+		tokenValue{TString, "key"},
+		tokenValue{TCloseBracket, "]"},
+		tokenValue{TEncapsedAndWhitespace, ", "},
+		tokenValue{TCurlyOpen, "{"},
+		tokenValue{TVariable, "$a"},
+		tokenValue{TOpenBracket, "["},
+		tokenValue{TConstantEncapsedString, "'b'"},
+		tokenValue{TCloseBracket, "]"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TEncapsedAndWhitespace, ` \$x `},
+		tokenValue{TDollarOpenCurlyBraces, "${"},
+		tokenValue{TStringVarName, "c"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TEncapsedAndWhitespace, "!"},
+		tokenValue{TDoubleQuote, `"`},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, `<?php "$a->b?->c $d[-1]{$e}";`,
+		tokenValue{TDoubleQuote, `"`},
+		tokenValue{TVariable, "$a"},
+		tokenValue{TObjectOperator, "->"},
+		tokenValue{TString, "b"},
+		tokenValue{TEncapsedAndWhitespace, "?->c "},
+		tokenValue{TVariable, "$d"},
+		tokenValue{TOpenBracket, "["},
+		tokenValue{TDash, "-"},
+		tokenValue{TNumString, "1"},
+		tokenValue{TCloseBracket, "]"},
+		tokenValue{TCurlyOpen, "{"},
+		tokenValue{TVariable, "$e"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TDoubleQuote, `"`},
+		tokenValue{TSemiColon, ";"},
+	)
+}
+
+func TestBacktick(t *testing.T) {
+	assertTokens(t, "<?php `ls $dir`; ``;",
+		tokenValue{TBacktick, "`"},
+		tokenValue{TEncapsedAndWhitespace, "ls "},
+		tokenValue{TVariable, "$dir"},
+		tokenValue{TBacktick, "`"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TBacktick, "`"},
+		tokenValue{TBacktick, "`"},
+		tokenValue{TSemiColon, ";"},
+	)
+}
+
+func TestNumbers(t *testing.T) {
+	numbers := []tokenValue{
+		{TLNumber, "0"},
+		{TLNumber, "42"},
+		{TLNumber, "0x1F"},
+		{TLNumber, "0XdeadBEEF"},
+		{TLNumber, "0b1010"},
+		{TLNumber, "0o17"},
+		{TLNumber, "0O17"},
+		{TLNumber, "0777"},
+		{TLNumber, "1_000_000"},
+		{TLNumber, "0x7FFF_FFFF"},
+		{TLNumber, "9223372036854775807"},
+		{TDNumber, "9223372036854775808"},
+		{TDNumber, "0x8000000000000000"},
+		{TDNumber, "01000000000000000000000"},
+		{TDNumber, "0b11111111111111111111111111111111111111111111111111111111111111111"},
+		{TDNumber, "1e10"},
+		{TDNumber, "1E-10"},
+		{TDNumber, "2.5e+3"},
+		{TDNumber, ".5"},
+		{TDNumber, "1."},
+		{TDNumber, "1.5"},
+		{TDNumber, "1_000.000_1"},
+		{TDNumber, "1.e3"},
+	}
+
+	for _, number := range numbers {
+		assertTokens(t, "<?php "+number.value+";", number, tokenValue{TSemiColon, ";"})
+	}
+}
+
+func TestNumbersSplitLikePHP(t *testing.T) {
+	assertTokens(t, "<?php 1__000;", tokenValue{TLNumber, "1"}, tokenValue{TString, "__000"}, tokenValue{TSemiColon, ";"})
+	assertTokens(t, "<?php 1_;", tokenValue{TLNumber, "1"}, tokenValue{TString, "_"}, tokenValue{TSemiColon, ";"})
+	assertTokens(t, "<?php 0x;", tokenValue{TLNumber, "0"}, tokenValue{TString, "x"}, tokenValue{TSemiColon, ";"})
+	assertTokens(t, "<?php 1e;", tokenValue{TLNumber, "1"}, tokenValue{TString, "e"}, tokenValue{TSemiColon, ";"})
+	assertTokens(t, "<?php $a.5;", tokenValue{TVariable, "$a"}, tokenValue{TDNumber, ".5"}, tokenValue{TSemiColon, ";"})
+	assertTokens(t, "<?php $a . 'b';", tokenValue{TVariable, "$a"}, tokenValue{TConcat, "."}, tokenValue{TConstantEncapsedString, "'b'"}, tokenValue{TSemiColon, ";"})
+}
+
+func TestNames(t *testing.T) {
+	assertTokens(t, `<?php new \Foo\Bar(namespace\Baz::X, App\Models\User::class, \strlen(''));`,
+		tokenValue{TNew, "new"},
+		tokenValue{TNameFullyQualified, `\Foo\Bar`},
+		tokenValue{TOpenParen, "("},
+		tokenValue{TNameRelative, `namespace\Baz`},
+		tokenValue{TPaamayimNekudotayim, "::"},
+		tokenValue{TString, "X"},
+		tokenValue{TComma, ","},
+		tokenValue{TNameQualified, `App\Models\User`},
+		tokenValue{TPaamayimNekudotayim, "::"},
+		tokenValue{TClass, "class"},
+		tokenValue{TComma, ","},
+		tokenValue{TNameFullyQualified, `\strlen`},
+		tokenValue{TOpenParen, "("},
+		tokenValue{TConstantEncapsedString, "''"},
+		tokenValue{TCloseParen, ")"},
+		tokenValue{TCloseParen, ")"},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, `<?php use App\{Foo, Bar\Baz};`,
+		tokenValue{TUse, "use"},
+		tokenValue{TString, "App"},
+		tokenValue{TNsSeparator, `\`},
+		tokenValue{TOPENCurly, "{"},
+		tokenValue{TString, "Foo"},
+		tokenValue{TComma, ","},
+		tokenValue{TNameQualified, `Bar\Baz`},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertKinds(t, "<?php namespace App\\Http; namespace\\foo();", TNamespace, TNameQualified, TSemiColon, TNameRelative, TOpenParen, TCloseParen, TSemiColon)
+}
+
+func TestVariables(t *testing.T) {
+	assertTokens(t, "<?php var $a; $$b; ${'c'}; $über = $_1;",
+		tokenValue{TVar, "var"},
+		tokenValue{TVariable, "$a"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TDollar, "$"},
+		tokenValue{TVariable, "$b"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TDollar, "$"},
+		tokenValue{TOPENCurly, "{"},
+		tokenValue{TConstantEncapsedString, "'c'"},
+		tokenValue{TCloseCurly, "}"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TVariable, "$über"},
+		tokenValue{TAssignment, "="},
+		tokenValue{TVariable, "$_1"},
+		tokenValue{TSemiColon, ";"},
+	)
+
+	assertTokens(t, "<?php $obj->straße; Größe::new();",
+		tokenValue{TVariable, "$obj"},
+		tokenValue{TObjectOperator, "->"},
+		tokenValue{TString, "straße"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TString, "Größe"},
+		tokenValue{TPaamayimNekudotayim, "::"},
+		tokenValue{TString, "new"},
+		tokenValue{TOpenParen, "("},
+		tokenValue{TCloseParen, ")"},
+		tokenValue{TSemiColon, ";"},
+	)
+}
+
+func TestDiagnostics(t *testing.T) {
+	source := "<?php\n$a = 1;\n  $b = \x01\x01 2;\n/* never closed"
+
+	tokens, diagnostics := TokenizeWithDiagnostics(source)
+
+	expected := []Diagnostic{
+		{Message: "unexpected character '\\x01'", Position: Position{21, 3, 8}, Snippet: "\x01\x01 2;"},
+		{Message: "unexpected character '\\x01'", Position: Position{22, 3, 9}, Snippet: "\x01 2;"},
+		{Message: "unterminated comment", Position: Position{27, 4, 1}, Snippet: "/* never closed"},
+	}
+
+	if !slices.Equal(diagnostics, expected) {
+		t.Fatalf("expected diagnostics %v, got %v", expected, diagnostics)
+	}
+
+	var joined strings.Builder
+	bad := 0
+	for _, token := range tokens {
+		joined.WriteString(token.Value)
+		if token.Kind == TBadCharacter {
+			bad++
+		}
+	}
+
+	if bad != 2 {
+		t.Errorf("expected 2 T_BAD_CHARACTER tokens, got %d", bad)
+	}
+
+	if joined.String() != source {
+		t.Errorf("tokens don't cover the whole source after recovering, got %q", joined.String())
+	}
+}
+
+func TestInvalidNumericLiterals(t *testing.T) {
+	sources := map[string]bool{
+		"<?php 089;":                        true,
+		"<?php 0_8;":                        true,
+		"<?php 07777777777777777777777779;": true,
+		"<?php 0_7;":                        false,
+		"<?php 0x89;":                       false,
+		"<?php 0.89;":                       false,
+		"<?php 089.5;":                      false,
+	}
+
+	for source, invalid := range sources {
+		tokens, diagnostics := TokenizeWithDiagnostics(source)
+
+		if !invalid {
+			if len(diagnostics) > 0 {
+				t.Errorf("lexing %q: expected no diagnostics, got %v", source, diagnostics)
+			}
+			continue
+		}
+
+		if len(diagnostics) != 1 || diagnostics[0].Message != "invalid numeric literal" || diagnostics[0].Position.Offset != len("<?php ") {
+			t.Errorf("lexing %q: expected a single invalid numeric literal diagnostic at offset 6, got %v", source, diagnostics)
+		}
+
+		if tokens[1].Kind != TLNumber && tokens[1].Kind != TDNumber || tokens[1].End.Offset != len(source)-1 {
+			t.Errorf("lexing %q: expected the literal to stay a single number token, got %v", source, tokens[1])
+		}
+	}
+}
+
+func TestUnterminatedLiterals(t *testing.T) {
+	sources := map[string]string{
+		"<?php 'abc":               "unterminated string",
+		"<?php \"abc $d":           "unterminated string",
+		"<?php `ls":                "unterminated string",
+		"<?php <<<EOT\nabc $d\n":   "unterminated heredoc",
+		"<?php <<<'EOT'\nabc $d\n": "unterminated nowdoc",
+	}
+
+	for source, message := range sources {
+		tokens, diagnostics := TokenizeWithDiagnostics(source)
+
+		if len(diagnostics) != 1 || diagnostics[0].Message != message || diagnostics[0].Position.Offset != len("<?php ") {
+			t.Errorf("lexing %q: expected a single %q diagnostic at offset 6, got %v", source, message, diagnostics)
+		}
+
+		if tokens[len(tokens)-1].End.Offset != len(source) {
+			t.Errorf("lexing %q: tokens don't cover the whole source", source)
+		}
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	f.Add("<?php echo \"a {$b[1]} ${c}\";")
+	f.Add("<?php $x = <<<EOT\n  $y->z\n  EOT;")
+	f.Add("<h1><?php /* x */ 0x1F ?>")
+
+	f.Fuzz(func(t *testing.T, source string) {
+		var joined strings.Builder
+		for _, token := range Tokenize(source) {
+			joined.WriteString(token.Value)
+		}
+
+		if joined.String() != source {
+			t.Errorf("joining the tokens of %q gives %q", source, joined.String())
+		}
+	})
+}
+
+func TestOperatorKinds(t *testing.T) {
+	assertKinds(t, "<?php !$a; @$b; $c <> $d; $e ^ ~$f; $g == $h;", TExclamationMark, TVariable, TSemiColon, TAt, TVariable, TSemiColon, TVariable, TIsNotEqual, TVariable, TSemiColon, TVariable, TCaret, TTilde, TVariable, TSemiColon, TVariable, TIsEqual, TVariable, TSemiColon)
+	assertKinds(t, "<?php $a !== $b <= $c << $d >>= $e;", TVariable, TIsNotIdentical, TVariable, TIsSmallerOrEqual, TVariable, TSl, TVariable, TSrEqual, TVariable, TSemiColon)
+	assertKinds(t, "<?php $sample = $amp & $example;", TVariable, TAssignment, TVariable, TAmpersandFollowedByVarOrVararg, TVariable, TSemiColon)
+}
+
+func TestAmpersand(t *testing.T) {
+	assertKinds(t, "<?php function f(&$a, & ...$b, A&B $c) { $d = &$e; $f & /* x */ $g; $h & 1; $i && $j; $k &= 2; }",
+		TFunction, TString, TOpenParen, TAmpersandFollowedByVarOrVararg, TVariable, TComma, TAmpersandFollowedByVarOrVararg, TEllipsis, TVariable, TComma, TString, TAmpersandNotFollowedByVarOrVararg, TString, TVariable, TCloseParen,
+		TOPENCurly, TVariable, TAssignment, TAmpersandFollowedByVarOrVararg, TVariable, TSemiColon, TVariable, TAmpersandFollowedByVarOrVararg, TComment, TVariable, TSemiColon,
+		TVariable, TAmpersandNotFollowedByVarOrVararg, TLNumber, TSemiColon, TVariable, TBooleanAnd, TVariable, TSemiColon, TVariable, TAndEqual, TLNumber, TSemiColon, TCloseCurly)
+}
+
+func TestCasts(t *testing.T) {
+	casts := []tokenValue{
+		{TIntCast, "(int)"},
+		{TIntCast, "( int )"},
+		{TIntCast, "(INTEGER)"},
+		{TBoolCast, "(bool)"},
+		{TBoolCast, "(\tboolean)"},
+		{TDoubleCast, "(float)"},
+		{TDoubleCast, "(double)"},
+		{TDoubleCast, "(real)"},
+		{TStringCast, "(string)"},
+		{TStringCast, "(binary)"},
+		{TArrayCast, "(array)"},
+		{TObjectCast, "(object)"},
+		{TUnsetCast, "(unset)"},
+	}
+
+	for _, cast := range casts {
+		assertTokens(t, "<?php "+cast.value+"$a;", cast, tokenValue{TVariable, "$a"}, tokenValue{TSemiColon, ";"})
+	}
+
+	assertKinds(t, "<?php int($a); (integer_value); (\nint);", TString, TOpenParen, TVariable, TCloseParen, TSemiColon, TOpenParen, TString, TCloseParen, TSemiColon, TOpenParen, TString, TCloseParen, TSemiColon)
+}
+
+func TestComments(t *testing.T) {
+	assertTokens(t, "<?php # shell\n// line\r\n$a; // end ?>html",
+		tokenValue{TComment, "# shell"},
+		tokenValue{TComment, "// line"},
+		tokenValue{TVariable, "$a"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TComment, "// end "},
+		tokenValue{TCloseTag, "?>"},
+		tokenValue{TInlineHtml, "html"},
+	)
+
+	assertTokens(t, "<?php #[Attr] #comment",
+		tokenValue{TAttribute, "#["},
+		tokenValue{TString, "Attr"},
+		tokenValue{TCloseBracket, "]"},
+		tokenValue{TComment, "#comment"},
+	)
+
+	assertTokens(t, "<?php /** doc */ /**\n * doc\n */ /**/ /***/ /*** banner ***/ /* plain */",
+		tokenValue{TDocComment, "/** doc */"},
+		tokenValue{TDocComment, "/**\n * doc\n */"},
+		tokenValue{TComment, "/**/"},
+		tokenValue{TComment, "/***/"},
+		tokenValue{TComment, "/*** banner ***/"},
+		tokenValue{TComment, "/* plain */"},
+	)
+
+	// Since PHP 8.0, the line break ending a comment is whitespace of its own.
+	source := "<?php\n// hi\r\n$a; # x\n"
+	expected := map[Version][]string{
+		PHP74: {"<?php\n", "// hi\r\n", "$a", ";", " ", "# x\n", ""},
+		PHP80: {"<?php\n", "// hi", "\r\n", "$a", ";", " ", "# x", "\n", ""},
+	}
+	for version, values := range expected {
+		tokens, _ := TokenizeWithOptions(source, Options{Version: version})
+
+		var got []string
+		for _, token := range tokens {
+			got = append(got, token.Value)
+		}
+		if !slices.Equal(got, values) {
+			t.Errorf("lexing %q for %s\nexpected %q\ngot      %q", source, version, values, got)
+		}
+	}
+}
+
+func TestOpenAndCloseTags(t *testing.T) {
+	assertTokens(t, "<?xml version=\"1.0\"?>\n<?= $a ?>\n<p><?PHP\r\necho 1 ?>\r\n</p><?php",
+		tokenValue{TInlineHtml, "<?xml version=\"1.0\"?>\n"},
+		tokenValue{TOpenTagWithEcho, "<?="},
+		tokenValue{TVariable, "$a"},
+		tokenValue{TCloseTag, "?>\n"},
+		tokenValue{TInlineHtml, "<p>"},
+		tokenValue{TEcho, "echo"},
+		tokenValue{TLNumber, "1"},
+		tokenValue{TCloseTag, "?>\r\n"},
+		tokenValue{TInlineHtml, "</p>"},
+	)
+
+	tokens := Tokenize("<p><?PHP\r\necho 1; <?phpx")
+	if tokens[1].Kind != TOpenTag || tokens[1].Value != "<?PHP\r\n" {
+		t.Errorf("expected the open tag to take in the line break, got %s %q", TokenKindString(tokens[1].Kind), tokens[1].Value)
+	}
+
+	if tokens := Tokenize("<?phpx <? echo 1;"); len(tokens) != 2 || tokens[0].Kind != TInlineHtml {
+		t.Errorf("expected only inline HTML without short open tags, got %v", tokens)
+	}
+}
+
+func TestShortOpenTag(t *testing.T) {
+	tokens, _ := TokenizeWithOptions("a<? echo 1; <?phpx", Options{ShortOpenTag: true})
+
+	var got []tokenValue
+	for _, token := range tokens {
+		got = append(got, tokenValue{token.Kind, token.Value})
+	}
+
+	expected := []tokenValue{
+		{TInlineHtml, "a"},
+		{TOpenTag, "<?"},
+		{TWhitespace, " "},
+		{TEcho, "echo"},
+		{TWhitespace, " "},
+		{TLNumber, "1"},
+		{TSemiColon, ";"},
+		{TWhitespace, " "},
+		{TIsSmaller, "<"},
+		{TQuestion, "?"},
+		{TString, "phpx"},
+		{TEOF, ""},
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v\ngot      %v", expected, got)
+	}
+}
+
+func TestHaltCompiler(t *testing.T) {
+	assertTokens(t, "<?php echo 1; __HALT_COMPILER ( ) ; <?php $data = 'x'; ?>\x00\xff",
+		tokenValue{TEcho, "echo"},
+		tokenValue{TLNumber, "1"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{THaltCompiler, "__HALT_COMPILER"},
+		tokenValue{TOpenParen, "("},
+		tokenValue{TCloseParen, ")"},
+		tokenValue{TSemiColon, ";"},
+		tokenValue{TInlineHtml, " <?php $data = 'x'; ?>\x00\xff"},
+	)
+
+	assertKinds(t, "<?php $a->__halt_compiler(); echo 1;", TVariable, TObjectOperator, TString, TOpenParen, TCloseParen, TSemiColon, TEcho, TLNumber, TSemiColon)
+}
+
+func versionKinds(source string, version Version) []Kind {
+	tokens, _ := TokenizeWithOptions(source, Options{Version: version})
+
+	var kinds []Kind
+	for _, token := range tokens {
+		if !token.isOneOfMany(TWhitespace, TOpenTag, TEOF) {
+			kinds = append(kinds, token.Kind)
+		}
+	}
+	return kinds
+}
+
+func TestVersionKeywords(t *testing.T) {
+	source := "<?php match enum readonly __PROPERTY__ fn never"
+
+	expected := map[Version][]Kind{
+		PHP74: {TString, TString, TString, TString, TFn, TString},
+		PHP80: {TMatch, TString, TString, TString, TFn, TString},
+		PHP81: {TMatch, TEnum, TReadonly, TString, TFn, TString},
+		PHP83: {TMatch, TEnum, TReadonly, TString, TFn, TString},
+		PHP84: {TMatch, TEnum, TReadonly, TPropertyC, TFn, TString},
+	}
+
+	for version, kinds := range expected {
+		if got := versionKinds(source, version); !slices.Equal(got, kinds) {
+			t.Errorf("PHP %s: expected %v, got %v", version, kindNames(kinds), kindNames(got))
+		}
+	}
+}
+
+func TestVersionSyntax(t *testing.T) {
+	source := "<?php #[Attr]\n$a?->b; \\Foo\\Bar; namespace\\Baz; 0o17;"
+
+	php74 := []Kind{TComment, TVariable, TQuestion, TObjectOperator, TString, TSemiColon, TNsSeparator, TString, TNsSeparator, TString, TSemiColon, TNamespace, TNsSeparator, TString, TSemiColon, TLNumber, TString, TSemiColon}
+	if got := versionKinds(source, PHP74); !slices.Equal(got, php74) {
+		t.Errorf("PHP 7.4: expected %v, got %v", kindNames(php74), kindNames(got))
+	}
+
+	php81 := []Kind{TAttribute, TString, TCloseBracket, TVariable, TNullSafeObjectOperator, TString, TSemiColon, TNameFullyQualified, TSemiColon, TNameRelative, TSemiColon, TLNumber, TSemiColon}
+	if got := versionKinds(source, PHP81); !slices.Equal(got, php81) {
+		t.Errorf("PHP 8.1: expected %v, got %v", kindNames(php81), kindNames(got))
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	valid := map[string]Version{"7.4": PHP74, "8.0": PHP80, " 8.3 ": PHP83, "8.4": PHP84}
+	for input, expected := range valid {
+		if got, err := ParseVersion(input); err != nil || got != expected {
+			t.Errorf("parsing %q: expected %s, got %s (%v)", input, expected, got, err)
+		}
+	}
+
+	for _, input := range []string{"", "8", "7.3", "8.5", "eight", "8.x"} {
+		if _, err := ParseVersion(input); err == nil {
+			t.Errorf("parsing %q: expected an error", input)
+		}
+	}
+}
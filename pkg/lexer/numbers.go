@@ -0,0 +1,108 @@
+package lexer
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// scanNumber lexes an integer or float literal following the PHP 8.1 grammar: decimal,
+// hexadecimal (0x), binary (0b), octal (0o or a leading 0) and float literals, with "_"
+// allowed between digits. The "0o" prefix needs PHP 8.1. Like php, integers that overflow
+// a 64-bit int are floats, and a legacy octal literal with 8 or 9 in it, such as 089, is
+// reported as an invalid numeric literal.
+func (l *lexer) scanNumber() {
+	if l.at() == '0' {
+		if base, digit := numberPrefix(l.peek(1)); base != 0 && (base != 8 || l.supports(PHP81)) {
+			if n := l.digitsAt(l.pos+2, digit); n > 0 {
+				l.emit(integerKind(l.source[l.pos+2:l.pos+2+n], base), n+2)
+				return
+			}
+		}
+	}
+
+	n := l.digitsAt(l.pos, isDigit)
+	float := false
+
+	if l.peek(n) == '.' {
+		if fraction := l.digitsAt(l.pos+n+1, isDigit); n > 0 || fraction > 0 {
+			n += 1 + fraction
+			float = true
+		}
+	}
+
+	if c := l.peek(n); c == 'e' || c == 'E' {
+		sign := 0
+		if c := l.peek(n + 1); c == '+' || c == '-' {
+			sign = 1
+		}
+
+		if exponent := l.digitsAt(l.pos+n+1+sign, isDigit); exponent > 0 {
+			n += 1 + sign + exponent
+			float = true
+		}
+	}
+
+	if float {
+		l.emit(TDNumber, n)
+		return
+	}
+
+	literal := l.source[l.pos : l.pos+n]
+	if len(literal) > 1 && literal[0] == '0' {
+		if strings.ContainsAny(literal, "89") {
+			l.report(l.position(), "invalid numeric literal")
+		}
+		l.emit(integerKind(literal[1:], 8), n)
+		return
+	}
+
+	l.emit(integerKind(literal, 10), n)
+}
+
+// digitsAt returns the length of the run of digits starting at offset i, where single
+// underscores may separate two digits.
+func (l *lexer) digitsAt(i int, digit func(byte) bool) int {
+	start := i
+	for i < len(l.source) && digit(l.source[i]) {
+		i++
+		if l.byteAt(i) == '_' && digit(l.byteAt(i+1)) {
+			i++
+		}
+	}
+	return i - start
+}
+
+// integerKind returns TLNumber for digits that fit a 64-bit int in the given base and
+// TDNumber for those that overflow it.
+func integerKind(digits string, base int) Kind {
+	_, err := strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return TDNumber
+	}
+	return TLNumber
+}
+
+func numberPrefix(c byte) (int, func(byte) bool) {
+	switch c {
+	case 'x', 'X':
+		return 16, isHexDigit
+	case 'b', 'B':
+		return 2, isBinaryDigit
+	case 'o', 'O':
+		return 8, isOctalDigit
+	}
+	return 0, nil
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func isBinaryDigit(c byte) bool {
+	return c == '0' || c == '1'
+}
+
+func isOctalDigit(c byte) bool {
+	return c >= '0' && c <= '7'
+}
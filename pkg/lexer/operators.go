@@ -0,0 +1,151 @@
+package lexer
+
+import (
+	"slices"
+	"strings"
+)
+
+type operator struct {
+	value string
+	kind  Kind
+}
+
+// operators holds the punctuation tokens bucketed by their first byte. Every bucket is
+// ordered longest first, so the first entry that matches is the longest possible match.
+var operators = buildOperatorTable([]operator{
+	{";", TSemiColon},
+	{":", TColon},
+	{"?", TQuestion},
+	{"[", TOpenBracket},
+	{"]", TCloseBracket},
+	{"{", TOPENCurly},
+	{"}", TCloseCurly},
+	{"(", TOpenParen},
+	{")", TCloseParen},
+	{"->", TObjectOperator},
+	{"=>", TDoubleArrow},
+	{"+=", TPlusEqual},
+	{"-=", TMinusEqual},
+	{"*=", TMulEqual},
+	{"/=", TDivEqual},
+	{".=", TConcatEqual},
+	{".", TConcat},
+	{",", TComma},
+	{"%=", TModEqual},
+	{"&=", TAndEqual},
+	{"|=", TOrEqual},
+	{"^=", TXorEqual},
+	{"<<=", TSlEqual},
+	{">>=", TSrEqual},
+	{"**=", TPowEqual},
+	{"??=", TCoalesceEqual},
+	{"??", TCoalesce},
+	{"||", TBooleanOr},
+	{"@", TAt},
+	{"|", TPipe},
+	{"&&", TBooleanAnd},
+	{"&", TAmpersandNotFollowedByVarOrVararg},
+	{"==", TIsEqual},
+	{"!=", TIsNotEqual},
+	{"<>", TIsNotEqual},
+	{"!", TExclamationMark},
+	{"^", TCaret},
+	{"~", TTilde},
+	{"===", TIsIdentical},
+	{"!==", TIsNotIdentical},
+	{"<=>", TSpaceship},
+	{"<=", TIsSmallerOrEqual},
+	{">=", TIsGreaterOrEqual},
+	{"<", TIsSmaller},
+	{">", TIsGreater},
+	{"<<", TSl},
+	{">>", TSr},
+	{"=", TAssignment},
+	{"+", TPlus},
+	{"-", TDash},
+	{"/", TSlash},
+	{"*", TStar},
+	{"%", TPercent},
+	{"**", TPow},
+	{"#[", TAttribute},
+	{"++", TInc},
+	{"--", TDec},
+	{"?->", TNullSafeObjectOperator},
+	{"$", TDollar},
+	{"::", TPaamayimNekudotayim},
+	{"\\", TNsSeparator},
+	{"...", TEllipsis},
+})
+
+func buildOperatorTable(list []operator) [256][]operator {
+	var table [256][]operator
+
+	for _, op := range list {
+		table[op.value[0]] = append(table[op.value[0]], op)
+	}
+
+	for _, bucket := range table {
+		slices.SortStableFunc(bucket, func(a, b operator) int {
+			return len(b.value) - len(a.value)
+		})
+	}
+
+	return table
+}
+
+// matchOperator returns the longest operator the source starts with.
+func matchOperator(source string) (operator, bool) {
+	for _, op := range operators[source[0]] {
+		if strings.HasPrefix(source, op.value) {
+			return op, true
+		}
+	}
+
+	return operator{}, false
+}
+
+// casts maps the type names allowed in a cast, lower-cased, to the kind of the cast token.
+var casts = map[string]Kind{
+	"int":     TIntCast,
+	"integer": TIntCast,
+	"bool":    TBoolCast,
+	"boolean": TBoolCast,
+	"float":   TDoubleCast,
+	"double":  TDoubleCast,
+	"real":    TDoubleCast,
+	"string":  TStringCast,
+	"binary":  TStringCast,
+	"array":   TArrayCast,
+	"object":  TObjectCast,
+	"unset":   TUnsetCast,
+}
+
+// castLength returns the kind and length of a cast such as "(int)" or "( boolean )" at the
+// current position, or a length of 0 if there is none. Spaces and tabs are allowed inside
+// the parentheses and the type name is case-insensitive, as in php.
+func (l *lexer) castLength() (Kind, int) {
+	isBlank := func(c byte) bool { return c == ' ' || c == '\t' }
+
+	start := l.span(1, isBlank)
+	end := l.span(start, isLabelChar)
+	kind, ok := casts[strings.ToLower(l.source[l.pos+start:l.pos+end])]
+	if !ok {
+		return 0, 0
+	}
+
+	n := l.span(end, isBlank)
+	if l.peek(n) != ')' {
+		return 0, 0
+	}
+	return kind, n + 1
+}
+
+// ampersandKind tells the two PHP 8.1 ampersand tokens apart: one followed by a variable or
+// "...", as in by-reference parameters, and any other one, as in intersection types.
+func (l *lexer) ampersandKind() Kind {
+	next := l.skipTrivia(l.pos + 1)
+	if l.byteAt(next) == '$' || strings.HasPrefix(l.source[next:], "...") {
+		return TAmpersandFollowedByVarOrVararg
+	}
+	return TAmpersandNotFollowedByVarOrVararg
+}
@@ -0,0 +1,18 @@
+package lexer
+
+// Options changes how source is lexed. The zero value lexes like a default php install.
+type Options struct {
+	// ShortOpenTag makes "<?" open PHP code, like php's short_open_tag ini setting.
+	ShortOpenTag bool
+	// Version is the PHP version to lex for. It decides which words are keywords and
+	// which tokens exist at all; for example "?->" and name tokens need PHP 8.0. The zero
+	// value means LatestVersion.
+	Version Version
+}
+
+func (o Options) version() Version {
+	if o.Version == 0 {
+		return LatestVersion
+	}
+	return o.Version
+}
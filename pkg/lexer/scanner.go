@@ -0,0 +1,186 @@
+package lexer
+
+import (
+	"io"
+	"strings"
+)
+
+const (
+	// scannerChunkSize is how many bytes a Scanner asks its reader for at a time.
+	scannerChunkSize = 32 * 1024
+	// scannerLookahead is how many bytes a Scanner keeps buffered past a token before it
+	// trusts it. Some tokens depend on what follows them, such as "enum" or a named
+	// argument, and the lexer looks that far ahead at most, unless a long comment sits in
+	// between. Long tokens themselves read the input as they go, see lexer.available.
+	scannerLookahead = 1024
+	// scannerHistory is how many already returned tokens a Scanner keeps, so keywords that
+	// depend on the tokens before them are still lexed right.
+	scannerHistory = 16
+)
+
+// Scanner lexes PHP source read from an io.Reader one token at a time, without holding the
+// whole source or all of its tokens in memory. It returns the same tokens as Tokenize.
+type Scanner struct {
+	reader io.Reader
+	lex    *lexer
+	// buffer holds the source being lexed. It only grows until the lexed part is dropped,
+	// so the strings it returned, which tokens point into, stay valid.
+	buffer  strings.Builder
+	chunk   []byte
+	pending []Token
+	eof     bool
+	done    bool
+	err     error
+}
+
+// snapshot is the part of the lexer state a Scanner restores when a token turns out to
+// need more input than is buffered.
+type snapshot struct {
+	pos, line, column int
+	state             state
+	stack             []state
+	literals          []literal
+	tokens            int
+	diagnostics       int
+	halt              int
+}
+
+// NewScanner returns a Scanner reading from reader and lexing with the given options.
+func NewScanner(reader io.Reader, options Options) *Scanner {
+	lex := createLexer("")
+	lex.options = options
+
+	s := &Scanner{reader: reader, lex: lex, chunk: make([]byte, scannerChunkSize)}
+	lex.more = s.read
+	return s
+}
+
+// Next returns the next token. Once the source is exhausted it keeps returning T_EOF.
+// A read error ends the source early; it is returned by Err.
+func (s *Scanner) Next() Token {
+	for len(s.pending) == 0 {
+		if s.done {
+			return Token{Kind: TEOF, Start: s.lex.position(), End: s.lex.position()}
+		}
+		s.scan()
+	}
+
+	token := s.pending[0]
+	s.pending = s.pending[1:]
+	return token
+}
+
+// Diagnostics returns the problems found in the tokens returned so far, and possibly in
+// a few buffered ones.
+func (s *Scanner) Diagnostics() []Diagnostic {
+	return s.lex.diagnostics
+}
+
+// Err returns the first error returned by the reader, other than io.EOF.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// scan lexes the next step into pending. Long tokens read the input they need while they
+// are lexed, but a step that ends too close to the end of the buffered source may have
+// looked past it, so it is lexed again with more input.
+func (s *Scanner) scan() {
+	for !s.eof && len(s.lex.remainder()) < scannerLookahead {
+		s.fill()
+	}
+
+	if s.lex.atEof() {
+		mark := len(s.lex.Tokens)
+		s.lex.finish()
+		s.flush(mark)
+		s.done = true
+		return
+	}
+
+	saved := s.save()
+	s.lex.step()
+
+	for !s.eof && s.lex.pos+scannerLookahead > len(s.lex.source) {
+		s.restore(saved)
+		s.fill()
+		s.lex.step()
+	}
+
+	s.flush(saved.tokens)
+	s.trim()
+}
+
+// read fills the buffer with the next chunk of the reader, for the lexer in the middle of a
+// long token. It reports whether the reader had anything left.
+func (s *Scanner) read() bool {
+	if s.eof {
+		return false
+	}
+
+	s.fill()
+	return true
+}
+
+// fill appends the next chunk of the reader to the source. It reads at least the lookahead,
+// so that a reader returning a few bytes at a time doesn't make every token lexed again.
+func (s *Scanner) fill() {
+	n, err := io.ReadAtLeast(s.reader, s.chunk, scannerLookahead)
+	s.buffer.Write(s.chunk[:n])
+	s.lex.source = s.buffer.String()
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		s.eof = true
+	} else if err != nil {
+		s.eof = true
+		s.err = err
+	}
+}
+
+// flush moves the tokens lexed from index from onwards to pending.
+func (s *Scanner) flush(from int) {
+	s.pending = append(s.pending, s.lex.Tokens[from:]...)
+
+	if len(s.lex.Tokens) > 4*scannerHistory {
+		s.lex.Tokens = append(s.lex.Tokens[:0], s.lex.Tokens[len(s.lex.Tokens)-scannerHistory:]...)
+	}
+}
+
+// trim drops the source already lexed. An open literal is kept, since an unterminated one
+// is reported at its start.
+func (s *Scanner) trim() {
+	if s.lex.pos < scannerChunkSize || len(s.lex.literals) > 0 {
+		return
+	}
+
+	s.lex.base += s.lex.pos
+	s.buffer.Reset()
+	s.buffer.WriteString(s.lex.source[s.lex.pos:])
+	s.lex.source = s.buffer.String()
+	s.lex.pos = 0
+}
+
+func (s *Scanner) save() snapshot {
+	return snapshot{
+		pos:         s.lex.pos,
+		line:        s.lex.line,
+		column:      s.lex.column,
+		state:       s.lex.state,
+		stack:       append([]state(nil), s.lex.stack...),
+		literals:    append([]literal(nil), s.lex.literals...),
+		tokens:      len(s.lex.Tokens),
+		diagnostics: len(s.lex.diagnostics),
+		halt:        s.lex.halt,
+	}
+}
+
+func (s *Scanner) restore(saved snapshot) {
+	s.lex.pos = saved.pos
+	s.lex.line = saved.line
+	s.lex.column = saved.column
+	s.lex.state = saved.state
+	s.lex.stack = append(s.lex.stack[:0], saved.stack...)
+	s.lex.literals = append(s.lex.literals[:0], saved.literals...)
+	s.lex.Tokens = s.lex.Tokens[:saved.tokens]
+	s.lex.diagnostics = s.lex.diagnostics[:saved.diagnostics]
+	s.lex.halt = saved.halt
+}
@@ -0,0 +1,69 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func scanAll(scanner *Scanner) []Token {
+	var tokens []Token
+	for {
+		token := scanner.Next()
+		tokens = append(tokens, token)
+		if token.Kind == TEOF {
+			return tokens
+		}
+	}
+}
+
+func TestScannerMatchesTokenize(t *testing.T) {
+	sources := []string{
+		"",
+		"<h1>\n<?php\n$a = 10;\r\n  echo $a;",
+		"<?php enum Suit { case Hearts; } $x = \"a {$b->c} d\";\n<<<EOT\n  body $x\n  EOT;\n",
+		"<?php /* unterminated",
+		"<?php __halt_compiler(); raw data",
+		largePHPFile(200),
+	}
+	for _, source := range longTokenSources(100 * 1024) {
+		sources = append(sources, source)
+	}
+
+	for _, source := range sources {
+		expected, diagnostics := TokenizeWithOptions(source, Options{})
+
+		for _, reader := range []io.Reader{strings.NewReader(source), iotest.OneByteReader(strings.NewReader(source))} {
+			scanner := NewScanner(reader, Options{})
+			tokens := scanAll(scanner)
+
+			if !reflect.DeepEqual(tokens, expected) {
+				t.Fatalf("Scanning %.40q: got %d tokens, want %d", source, len(tokens), len(expected))
+			}
+			if !reflect.DeepEqual(scanner.Diagnostics(), diagnostics) && len(diagnostics) > 0 {
+				t.Fatalf("Scanning %.40q: got diagnostics %v, want %v", source, scanner.Diagnostics(), diagnostics)
+			}
+			if scanner.Next().Kind != TEOF {
+				t.Fatalf("Scanning %.40q: expected T_EOF after the end", source)
+			}
+		}
+	}
+}
+
+func TestScannerReadError(t *testing.T) {
+	failure := errors.New("disk on fire")
+	reader := io.MultiReader(strings.NewReader("<?php echo 1;"), iotest.ErrReader(failure))
+
+	scanner := NewScanner(reader, Options{})
+	tokens := scanAll(scanner)
+
+	if !errors.Is(scanner.Err(), failure) {
+		t.Fatalf("got error %v, want %v", scanner.Err(), failure)
+	}
+	if got := len(tokens); got != 6 {
+		t.Fatalf("got %d tokens, want 6", got)
+	}
+}
@@ -0,0 +1,89 @@
+package lexer
+
+// scanSingleQuoted lexes a single-quoted literal, which never contains interpolation.
+func (l *lexer) scanSingleQuoted() {
+	n := l.quotedLength('\'')
+	if n == 0 {
+		l.report(l.position(), "unterminated string")
+		l.emit(TEncapsedAndWhitespace, len(l.remainder()))
+		return
+	}
+
+	l.emit(TConstantEncapsedString, n)
+}
+
+// scanDoubleQuoted lexes a double-quoted literal. Like php's tokenizer, a literal without
+// interpolation is a single T_CONSTANT_ENCAPSED_STRING, while one with embedded variables is
+// split into its quotes, T_ENCAPSED_AND_WHITESPACE parts and the embedded code.
+func (l *lexer) scanDoubleQuoted() {
+	n := l.quotedLength('"')
+	if n == 0 {
+		l.enterQuoted(TDoubleQuote, stateDoubleQuotes)
+		return
+	}
+
+	for i := l.pos + 1; i < l.pos+n-1; i++ {
+		if l.source[i] == '\\' {
+			i++
+			continue
+		}
+
+		if l.interpolationAt(i) {
+			l.enterQuoted(TDoubleQuote, stateDoubleQuotes)
+			return
+		}
+	}
+
+	l.emit(TConstantEncapsedString, n)
+}
+
+// enterQuoted emits the opening quote of an interpolated string or command and starts
+// lexing its body.
+func (l *lexer) enterQuoted(kind Kind, s state) {
+	l.literals = append(l.literals, literal{start: l.position()})
+	l.emit(kind, 1)
+	l.pushState(s)
+}
+
+// quotedLength returns the length of the literal at the current position up to and
+// including its closing quote, skipping backslash escapes, or 0 if it is never closed.
+func (l *lexer) quotedLength(quote byte) int {
+	for i := l.pos + 1; l.available(i); i++ {
+		switch l.source[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1 - l.pos
+		}
+	}
+
+	return 0
+}
+
+// scanQuoted lexes the body of a double-quoted string or backtick command up to the next
+// interpolation or the closing quote, which is emitted as kind.
+func (l *lexer) scanQuoted(quote byte, kind Kind) {
+	if l.at() == quote {
+		l.emit(kind, 1)
+		l.literals = l.literals[:len(l.literals)-1]
+		l.popState()
+		return
+	}
+
+	if l.scanInterpolation() {
+		return
+	}
+
+	for i := l.pos; l.available(i); i++ {
+		if l.source[i] == quote || i > l.pos && l.interpolationAt(i) {
+			l.emit(TEncapsedAndWhitespace, i-l.pos)
+			return
+		}
+
+		if l.source[i] == '\\' {
+			i++
+		}
+	}
+
+	l.emit(TEncapsedAndWhitespace, len(l.remainder()))
+}
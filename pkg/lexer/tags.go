@@ -0,0 +1,76 @@
+package lexer
+
+import "strings"
+
+const (
+	// haltTokens is how many significant tokens php still lexes after __halt_compiler,
+	// which are the "(", ")" and ";" that complete the call.
+	haltTokens = 3
+	// haltReached marks that everything left is data, such as the payload of a phar stub.
+	haltReached = 0
+)
+
+// scanInlineHTML lexes the text before the next open tag and the open tag itself. A "<?" that
+// doesn't open PHP code, like in "<?xml", is part of the text.
+func (l *lexer) scanInlineHTML() {
+	for i := l.pos; ; i += len("<?") {
+		i = l.index(i, "<?")
+		if i == -1 {
+			l.emit(TInlineHtml, len(l.remainder()))
+			return
+		}
+
+		kind, n := l.openTagAt(i)
+		if n == 0 {
+			continue
+		}
+
+		if i > l.pos {
+			l.emit(TInlineHtml, i-l.pos)
+		}
+		l.emit(kind, n)
+		l.state = stateScripting
+		return
+	}
+}
+
+// openTagAt returns the kind and length of the open tag at offset i, or a length of 0 if
+// there is none. As in php, "<?php" is case-insensitive and takes in the single space, tab
+// or line break that must follow it, unless it ends the file.
+func (l *lexer) openTagAt(i int) (Kind, int) {
+	if l.byteAt(i+2) == '=' {
+		return TOpenTagWithEcho, len("<?=")
+	}
+
+	if i+len("<?php") <= len(l.source) && strings.EqualFold(l.source[i+2:i+5], "php") {
+		switch c := l.byteAt(i + 5); {
+		case i+5 == len(l.source):
+			return TOpenTag, len("<?php")
+		case c == '\r' && l.byteAt(i+6) == '\n':
+			return TOpenTag, len("<?php\r\n")
+		case isWhitespace(c):
+			return TOpenTag, len("<?php ")
+		}
+	}
+
+	if l.options.ShortOpenTag {
+		return TOpenTag, len("<?")
+	}
+
+	return 0, 0
+}
+
+// closeTagLength returns the length of the "?>" at the current position, including the
+// single line break right after it, which php swallows.
+func (l *lexer) closeTagLength() int {
+	switch {
+	case l.peek(2) == '\n':
+		return len("?>\n")
+	case l.peek(2) == '\r' && l.peek(3) == '\n':
+		return len("?>\r\n")
+	case l.peek(2) == '\r':
+		return len("?>\r")
+	}
+
+	return len("?>")
+}
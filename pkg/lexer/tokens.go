@@ -1,5 +1,3 @@
-// Package lexer is for parsing PHP syntax
-// List is taken from here https://github.com/php/php-src/blob/master/ext/tokenizer/tokenizer_data.c
 package lexer
 
 import (
@@ -7,6 +5,8 @@ import (
 	"slices"
 )
 
+// Kind is the type of a token. The list is taken from here
+// https://github.com/php/php-src/blob/master/ext/tokenizer/tokenizer_data.c
 type Kind int
 
 const (
@@ -68,7 +68,6 @@ const (
 	TBooleanAnd
 	TAmpersandNotFollowedByVarOrVararg
 	TAmpersandFollowedByVarOrVararg
-	TNotEqual
 	TIsEqual
 	TIsNotEqual
 	TIsIdentical
@@ -184,11 +183,37 @@ const (
 	TCloseCurly
 	TOpenParen
 	TCloseParen
+	TDoubleQuote
+	TBacktick
+	TDollar
+	TExclamationMark
+	TCaret
+	TTilde
+
+	// kindCount is the number of kinds; new kinds go above it.
+	kindCount
 )
 
+// Position is a location in the source. Offset is 0-based, Line and Column are 1-based,
+// and Column is counted in bytes.
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Token is a single lexeme. Value is the exact source text of the token, so joining the
+// values of every token gives back the original source. Start is the position of its
+// first byte and End the position right after its last one.
 type Token struct {
-	Value string
-	Kind  Kind
+	Value string   `json:"value"`
+	Kind  Kind     `json:"kind"`
+	Start Position `json:"start"`
+	End   Position `json:"end"`
 }
 
 func (t Token) isOneOfMany(tokens ...Kind) bool {
@@ -198,17 +223,33 @@ func (t Token) isOneOfMany(tokens ...Kind) bool {
 	return false
 }
 func (t Token) Debug() {
-	if t.isOneOfMany(TLNumber, TDNumber, TString) {
-		fmt.Printf("%s (%s)\n", TokenKindString(t.Kind), t.Value)
+	if t.isOneOfMany(TLNumber, TDNumber, TString, TConstantEncapsedString, TEncapsedAndWhitespace) {
+		fmt.Printf("%s %s (%s)\n", t.Start, TokenKindString(t.Kind), t.Value)
 	} else {
-		fmt.Printf("%s ()\n", TokenKindString(t.Kind))
+		fmt.Printf("%s %s ()\n", t.Start, TokenKindString(t.Kind))
 	}
 }
 
+// NewToken returns a token without a position, such as one a fixer inserts.
 func NewToken(kind Kind, value string) Token {
-	return Token{value, kind}
+	return Token{Value: value, Kind: kind}
+}
+
+// TokenKindIsCharacter reports whether php's tokenizer returns tokens of this kind as plain
+// one-character strings, such as ";" or "(", rather than as [id, text, line] arrays.
+func TokenKindIsCharacter(token Kind) bool {
+	switch token {
+	case TAssignment, TPlus, TDash, TSlash, TStar, TPercent, TConcat, TComma, TPipe, TAt,
+		TExclamationMark, TCaret, TTilde, TIsSmaller, TIsGreater, TSemiColon, TColon, TQuestion,
+		TOpenBracket, TCloseBracket, TOPENCurly, TCloseCurly, TOpenParen, TCloseParen,
+		TDoubleQuote, TBacktick, TDollar:
+		return true
+	default:
+		return false
+	}
 }
 
+// TokenKindString returns the name of a kind, such as "T_STRING". Kind.String returns the same.
 func TokenKindString(token Kind) string {
 	switch token {
 	case TLNumber:
@@ -449,8 +490,6 @@ func TokenKindString(token Kind) string {
 		return "T_IS_EQUAL"
 	case TIsNotEqual:
 		return "T_IS_NOT_EQUAL"
-	case TNotEqual:
-		return "T_NOT_EQUAL"
 	case TIsIdentical:
 		return "T_IS_IDENTICAL"
 	case TIsNotIdentical:
@@ -531,12 +570,18 @@ func TokenKindString(token Kind) string {
 		return "T_AMPERSAND_NOT_FOLLOWED_BY_VAR_OR_VARARG"
 	case TBadCharacter:
 		return "T_BAD_CHARACTER"
+	case TError:
+		return "T_ERROR"
+	case TEOF:
+		return "T_EOF"
+	case TNoElse:
+		return "T_NOELSE"
 	case TSemiColon:
 		return "T_SEMI_COLON"
 	case TColon:
 		return "T_COLON"
 	case TQuestion:
-		return "T_question"
+		return "T_QUESTION"
 	case TOpenBracket:
 		return "T_OPEN_BRACKET"
 	case TCloseBracket:
@@ -549,6 +594,18 @@ func TokenKindString(token Kind) string {
 		return "T_OPEN_PAREN"
 	case TCloseParen:
 		return "T_CLOSE_PAREN"
+	case TDoubleQuote:
+		return "T_DOUBLE_QUOTE"
+	case TBacktick:
+		return "T_BACKTICK"
+	case TDollar:
+		return "T_DOLLAR"
+	case TExclamationMark:
+		return "T_EXCLAMATION_MARK"
+	case TCaret:
+		return "T_CARET"
+	case TTilde:
+		return "T_TILDE"
 	default:
 		return "T_BAD_CHARACTER"
 	}
@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a PHP language version, written as major*100 + minor, so PHP81 is PHP 8.1.
+type Version int
+
+const (
+	PHP74 Version = 704
+	PHP80 Version = 800
+	PHP81 Version = 801
+	PHP82 Version = 802
+	PHP83 Version = 803
+	PHP84 Version = 804
+
+	OldestVersion = PHP74
+	LatestVersion = PHP84
+)
+
+// ParseVersion parses a version such as "8.1". Only versions from 7.4 to 8.4 are supported.
+func ParseVersion(version string) (Version, error) {
+	major, minor, found := strings.Cut(strings.TrimSpace(version), ".")
+	majorNumber, majorErr := strconv.Atoi(major)
+	minorNumber, minorErr := strconv.Atoi(minor)
+
+	if !found || majorErr != nil || minorErr != nil || minorNumber < 0 || minorNumber > 99 {
+		return 0, fmt.Errorf("invalid PHP version %q, expected a version such as 8.3", version)
+	}
+
+	parsed := Version(majorNumber*100 + minorNumber)
+	if parsed < OldestVersion || parsed > LatestVersion {
+		return 0, fmt.Errorf("unsupported PHP version %q, expected %s to %s", version, OldestVersion, LatestVersion)
+	}
+
+	return parsed, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d", v/100, v%100)
+}
+
+// keywordVersions holds the keywords that older versions of PHP lex as plain T_STRING names,
+// with the version that reserved them. "never" and "mixed" are missing on purpose: they are
+// reserved type names, not keywords, and are T_STRING in every version.
+var keywordVersions = map[Kind]Version{
+	TMatch:     PHP80,
+	TEnum:      PHP81,
+	TReadonly:  PHP81,
+	TPropertyC: PHP84,
+}
+
+// supports reports whether the target version of the lexer is at least version.
+func (l *lexer) supports(version Version) bool {
+	return l.options.version() >= version
+}
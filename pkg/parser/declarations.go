@@ -0,0 +1,409 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+)
+
+// memberModifiers are the modifiers of properties, methods and class constants.
+var memberModifiers = []lexer.Kind{
+	lexer.TPublic, lexer.TProtected, lexer.TPrivate, lexer.TStatic, lexer.TAbstract, lexer.TFinal,
+	lexer.TReadonly, lexer.TVar,
+}
+
+// classModifiers are the modifiers of class declarations.
+var classModifiers = []lexer.Kind{lexer.TAbstract, lexer.TFinal, lexer.TReadonly}
+
+// promotionModifiers are the modifiers of promoted constructor parameters.
+var promotionModifiers = []lexer.Kind{lexer.TPublic, lexer.TProtected, lexer.TPrivate, lexer.TReadonly}
+
+// isFunctionDeclaration reports whether the function keyword n tokens ahead starts a named
+// function rather than a closure.
+func (p *parser) isFunctionDeclaration(n int) bool {
+	if p.peek(n+1).Kind == lexer.TAmpersandFollowedByVarOrVararg || p.peek(n+1).Kind == lexer.TAmpersandNotFollowedByVarOrVararg {
+		n++
+	}
+	return p.peek(n+1).Kind != lexer.TOpenParen
+}
+
+// parseFunctionDeclaration parses a named function into n, which may already hold its
+// attributes.
+func (p *parser) parseFunctionDeclaration(n *Node) *Node {
+	n.Kind = FunctionDeclaration
+	p.expect(n, lexer.TFunction)
+	p.parseFunctionSignature(n, true)
+	n.add(p.parseBlock())
+	return n
+}
+
+// parseFunctionSignature parses what follows the function keyword: an optional "&", the
+// name if named is set, the parameters and the return type.
+func (p *parser) parseFunctionSignature(n *Node, named bool) {
+	p.optional(n, lexer.TAmpersandFollowedByVarOrVararg, lexer.TAmpersandNotFollowedByVarOrVararg)
+	if named {
+		p.expectIdentifier(n)
+	}
+	n.add(p.parseParameters())
+
+	if p.at(lexer.TUse) && n.Kind == Closure {
+		n.add(p.parseClosureUses())
+	}
+
+	if p.optional(n, lexer.TColon) {
+		n.add(p.parseType())
+	}
+}
+
+// parseClassLike parses a class, interface, trait or enum into n, which may already hold
+// its attributes. It returns nil, without consuming anything, if the current tokens don't
+// start one.
+func (p *parser) parseClassLike(n *Node) *Node {
+	i := 0
+	for isOneOf(p.peek(i).Kind, classModifiers) {
+		i++
+	}
+
+	switch p.peek(i).Kind {
+	case lexer.TClass:
+		n.Kind = ClassDeclaration
+	case lexer.TInterface:
+		n.Kind = InterfaceDeclaration
+	case lexer.TTrait:
+		n.Kind = TraitDeclaration
+	case lexer.TEnum:
+		n.Kind = EnumDeclaration
+	default:
+		return nil
+	}
+
+	for range i {
+		p.consume(n)
+	}
+	p.consume(n)
+	p.expectIdentifier(n)
+
+	if n.Kind == EnumDeclaration && p.optional(n, lexer.TColon) {
+		n.add(p.parseType())
+	}
+	if p.at(lexer.TExtends) {
+		n.add(p.parseNameList(Extends))
+	}
+	if p.at(lexer.TImplements) {
+		n.add(p.parseNameList(Implements))
+	}
+
+	n.add(p.parseClassBody())
+	return n
+}
+
+// parseNameList parses a keyword followed by comma separated names, like "extends A, B".
+func (p *parser) parseNameList(kind NodeKind) *Node {
+	n := &Node{Kind: kind}
+	p.consume(n)
+	for {
+		n.add(p.parseName())
+		if !p.optional(n, lexer.TComma) {
+			return n
+		}
+	}
+}
+
+func (p *parser) parseClassBody() *Node {
+	n := &Node{Kind: ClassBody}
+	if !p.expect(n, lexer.TOPENCurly) {
+		return n
+	}
+
+	for !p.at(lexer.TCloseCurly) && !p.atEOF() {
+		n.add(p.parseMember())
+	}
+
+	p.expect(n, lexer.TCloseCurly)
+	return n
+}
+
+// parseMember parses a property, method, constant, trait use or enum case.
+func (p *parser) parseMember() *Node {
+	if p.at(lexer.TUse) {
+		return p.parseTraitUse()
+	}
+
+	n := &Node{}
+	p.parseAttributes(n)
+	for p.at(memberModifiers...) {
+		p.consume(n)
+	}
+
+	switch {
+	case p.at(lexer.TCase):
+		n.Kind = EnumCase
+		p.consume(n)
+		p.expectIdentifier(n)
+		if p.optional(n, lexer.TAssignment) {
+			n.add(p.parseExpression())
+		}
+		p.expectSemicolon(n)
+	case p.at(lexer.TConst):
+		n.Kind = ClassConstant
+		p.consume(n)
+		if p.peek(1).Kind != lexer.TAssignment {
+			n.add(p.parseType())
+		}
+		p.parseConstElements(n)
+		p.expectSemicolon(n)
+	case p.at(lexer.TFunction):
+		n.Kind = Method
+		p.consume(n)
+		p.parseFunctionSignature(n, true)
+		if !p.optional(n, lexer.TSemiColon) {
+			n.add(p.parseBlock())
+		}
+	case p.at(lexer.TVariable) || p.isTypeStart():
+		n.Kind = Property
+		if !p.at(lexer.TVariable) {
+			n.add(p.parseType())
+		}
+		for {
+			element := &Node{Kind: PropertyElement}
+			p.expect(element, lexer.TVariable)
+			if p.optional(element, lexer.TAssignment) {
+				element.add(p.parseExpression())
+			}
+			n.add(element)
+
+			if !p.optional(n, lexer.TComma) {
+				break
+			}
+		}
+		p.expectSemicolon(n)
+	default:
+		n.Kind = Error
+		p.error()
+		if !p.at(lexer.TCloseCurly) {
+			p.consume(n)
+		}
+	}
+
+	return n
+}
+
+// parseTraitUse parses "use A, B;" in a class, with an optional block of adaptations like
+// "A::foo insteadof B;" and "foo as protected bar;".
+func (p *parser) parseTraitUse() *Node {
+	n := p.parseNameList(TraitUse)
+	if p.optional(n, lexer.TSemiColon) {
+		return n
+	}
+
+	adaptations := &Node{Kind: TraitAdaptations}
+	p.expect(adaptations, lexer.TOPENCurly)
+	for !p.at(lexer.TCloseCurly) && !p.atEOF() {
+		adaptation := &Node{Kind: TraitAdaptation}
+		if p.peek(1).Kind == lexer.TPaamayimNekudotayim {
+			adaptation.add(p.parseName())
+			p.consume(adaptation)
+		}
+		p.expectIdentifier(adaptation)
+
+		switch {
+		case p.optional(adaptation, lexer.TInsteadof):
+			for {
+				adaptation.add(p.parseName())
+				if !p.optional(adaptation, lexer.TComma) {
+					break
+				}
+			}
+		case p.optional(adaptation, lexer.TAs):
+			if p.optional(adaptation, lexer.TPublic, lexer.TProtected, lexer.TPrivate) {
+				if !p.at(lexer.TSemiColon) {
+					p.expectIdentifier(adaptation)
+				}
+			} else {
+				p.expectIdentifier(adaptation)
+			}
+		default:
+			p.errorExpecting(`"as" or "insteadof"`)
+			if !p.at(lexer.TCloseCurly, lexer.TSemiColon) {
+				p.consume(adaptation)
+			}
+		}
+
+		p.expectSemicolon(adaptation)
+		adaptations.add(adaptation)
+	}
+	p.expect(adaptations, lexer.TCloseCurly)
+
+	n.add(adaptations)
+	return n
+}
+
+func (p *parser) parseParameters() *Node {
+	n := &Node{Kind: Parameters}
+	if !p.expect(n, lexer.TOpenParen) {
+		return n
+	}
+
+	for !p.at(lexer.TCloseParen) && !p.atEOF() {
+		parameter := &Node{Kind: Parameter}
+		p.parseAttributes(parameter)
+		for p.at(promotionModifiers...) {
+			p.consume(parameter)
+		}
+		if p.isTypeStart() {
+			parameter.add(p.parseType())
+		}
+		p.optional(parameter, lexer.TAmpersandFollowedByVarOrVararg)
+		p.optional(parameter, lexer.TEllipsis)
+		p.expect(parameter, lexer.TVariable)
+		if p.optional(parameter, lexer.TAssignment) {
+			parameter.add(p.parseExpression())
+		}
+		n.add(parameter)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+
+	p.expect(n, lexer.TCloseParen)
+	return n
+}
+
+// isTypeStart reports whether the current token can start a type declaration.
+func (p *parser) isTypeStart() bool {
+	return p.at(lexer.TQuestion, lexer.TOpenParen, lexer.TArray, lexer.TCallable, lexer.TStatic) || p.isNameStart(0)
+}
+
+// parseType parses a type declaration: a named type, a nullable one like ?int, a union, an
+// intersection or a union of intersections like (A&B)|null.
+func (p *parser) parseType() *Node {
+	if p.at(lexer.TQuestion) {
+		n := &Node{Kind: NullableType}
+		p.consume(n)
+		n.add(p.parseTypeAtom())
+		return n
+	}
+
+	first := p.parseTypeAtom()
+	var operator lexer.Kind
+	switch {
+	case p.at(lexer.TPipe):
+		operator = lexer.TPipe
+	case p.at(lexer.TAmpersandNotFollowedByVarOrVararg) && first.Kind == NamedType:
+		operator = lexer.TAmpersandNotFollowedByVarOrVararg
+	default:
+		return first
+	}
+
+	n := &Node{Kind: UnionType}
+	if operator != lexer.TPipe {
+		n.Kind = IntersectionType
+	}
+
+	n.add(first)
+	for p.optional(n, operator) {
+		n.add(p.parseTypeAtom())
+	}
+	return n
+}
+
+// parseTypeAtom parses a named type, or a parenthesized intersection in a union.
+func (p *parser) parseTypeAtom() *Node {
+	if p.at(lexer.TOpenParen) {
+		n := &Node{Kind: IntersectionType}
+		p.consume(n)
+		for {
+			n.add(p.parseTypeAtom())
+			if !p.optional(n, lexer.TAmpersandNotFollowedByVarOrVararg) {
+				break
+			}
+		}
+		p.expect(n, lexer.TCloseParen)
+		return n
+	}
+
+	n := &Node{Kind: NamedType}
+	if p.at(lexer.TArray, lexer.TCallable, lexer.TStatic) {
+		p.consume(n)
+	} else {
+		n.add(p.parseName())
+	}
+	return n
+}
+
+// parseAttributes parses the attribute groups, like #[Route('/'), Deprecated], at the
+// current token into n.
+func (p *parser) parseAttributes(n *Node) {
+	for p.at(lexer.TAttribute) {
+		group := &Node{Kind: AttributeGroup}
+		p.consume(group)
+
+		for !p.at(lexer.TCloseBracket) && !p.atEOF() {
+			attribute := &Node{Kind: Attribute}
+			attribute.add(p.parseName())
+			if p.at(lexer.TOpenParen) {
+				attribute.add(p.parseArguments())
+			}
+			group.add(attribute)
+
+			if !p.optional(group, lexer.TComma) {
+				break
+			}
+		}
+
+		p.expect(group, lexer.TCloseBracket)
+		n.add(group)
+	}
+}
+
+// isNameStart reports whether the token n tokens ahead starts a name.
+func (p *parser) isNameStart(n int) bool {
+	switch p.peek(n).Kind {
+	case lexer.TString, lexer.TNameQualified, lexer.TNameFullyQualified, lexer.TNameRelative:
+		return true
+	case lexer.TNsSeparator:
+		return p.peek(n+1).Kind == lexer.TString
+	case lexer.TNamespace:
+		return p.peek(n+1).Kind == lexer.TNsSeparator
+	}
+	return false
+}
+
+// parseName parses a name, like Foo, App\Foo, \Foo or namespace\Foo. PHP 8 lexes names as a
+// single token; before, they are made of T_STRING and T_NS_SEPARATOR tokens.
+func (p *parser) parseName() *Node {
+	n := &Node{Kind: Name}
+	if !p.isNameStart(0) {
+		p.errorExpecting("identifier")
+		return n
+	}
+
+	if p.at(lexer.TNameQualified, lexer.TNameFullyQualified, lexer.TNameRelative) {
+		p.consume(n)
+		return n
+	}
+
+	if p.optional(n, lexer.TNamespace) {
+		p.consume(n)
+	} else {
+		p.optional(n, lexer.TNsSeparator)
+	}
+
+	p.expect(n, lexer.TString)
+	for p.at(lexer.TNsSeparator) && p.peek(1).Kind == lexer.TString {
+		p.consume(n)
+		p.consume(n)
+	}
+	return n
+}
+
+// expectIdentifier adds an identifier to n. Where php expects the name of a method,
+// constant or enum case, any keyword is an identifier too.
+func (p *parser) expectIdentifier(n *Node) bool {
+	if p.at(lexer.TString) || p.current().Kind.IsKeyword() {
+		p.consume(n)
+		return true
+	}
+
+	p.errorExpecting("identifier")
+	return false
+}
@@ -0,0 +1,708 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+)
+
+// Operator precedences, from the loosest to the tightest binding, as in php's manual.
+// Assignments are parsed apart, since their left side can only be a variable.
+const (
+	precedenceLowest = iota
+	precedenceOr
+	precedenceXor
+	precedenceAnd
+	precedenceAssignment
+	precedenceTernary
+	precedenceCoalesce
+	precedenceBooleanOr
+	precedenceBooleanAnd
+	precedenceBitwiseOr
+	precedenceBitwiseXor
+	precedenceBitwiseAnd
+	precedenceEquality
+	precedenceComparison
+	precedenceConcat
+	precedenceShift
+	precedenceAdditive
+	precedenceMultiplicative
+	precedenceNot
+	precedenceInstanceof
+	precedenceUnary
+	precedencePow
+)
+
+type binaryOperator struct {
+	precedence int
+	right      bool
+}
+
+var binaryOperators = map[lexer.Kind]binaryOperator{
+	lexer.TLogicalOr:                         {precedenceOr, false},
+	lexer.TLogicalXor:                        {precedenceXor, false},
+	lexer.TLogicalAnd:                        {precedenceAnd, false},
+	lexer.TCoalesce:                          {precedenceCoalesce, true},
+	lexer.TBooleanOr:                         {precedenceBooleanOr, false},
+	lexer.TBooleanAnd:                        {precedenceBooleanAnd, false},
+	lexer.TPipe:                              {precedenceBitwiseOr, false},
+	lexer.TCaret:                             {precedenceBitwiseXor, false},
+	lexer.TAmpersandNotFollowedByVarOrVararg: {precedenceBitwiseAnd, false},
+	lexer.TAmpersandFollowedByVarOrVararg:    {precedenceBitwiseAnd, false},
+	lexer.TIsEqual:                           {precedenceEquality, false},
+	lexer.TIsNotEqual:                        {precedenceEquality, false},
+	lexer.TIsIdentical:                       {precedenceEquality, false},
+	lexer.TIsNotIdentical:                    {precedenceEquality, false},
+	lexer.TSpaceship:                         {precedenceEquality, false},
+	lexer.TIsSmaller:                         {precedenceComparison, false},
+	lexer.TIsSmallerOrEqual:                  {precedenceComparison, false},
+	lexer.TIsGreater:                         {precedenceComparison, false},
+	lexer.TIsGreaterOrEqual:                  {precedenceComparison, false},
+	lexer.TConcat:                            {precedenceConcat, false},
+	lexer.TSl:                                {precedenceShift, false},
+	lexer.TSr:                                {precedenceShift, false},
+	lexer.TPlus:                              {precedenceAdditive, false},
+	lexer.TDash:                              {precedenceAdditive, false},
+	lexer.TStar:                              {precedenceMultiplicative, false},
+	lexer.TSlash:                             {precedenceMultiplicative, false},
+	lexer.TPercent:                           {precedenceMultiplicative, false},
+	lexer.TInstanceof:                        {precedenceInstanceof, false},
+	lexer.TPow:                               {precedencePow, true},
+}
+
+var assignmentOperators = []lexer.Kind{
+	lexer.TAssignment, lexer.TPlusEqual, lexer.TMinusEqual, lexer.TMulEqual, lexer.TDivEqual,
+	lexer.TConcatEqual, lexer.TModEqual, lexer.TAndEqual, lexer.TOrEqual, lexer.TXorEqual,
+	lexer.TSlEqual, lexer.TSrEqual, lexer.TPowEqual, lexer.TCoalesceEqual,
+}
+
+var castKinds = []lexer.Kind{
+	lexer.TIntCast, lexer.TDoubleCast, lexer.TStringCast, lexer.TArrayCast, lexer.TObjectCast,
+	lexer.TBoolCast, lexer.TUnsetCast,
+}
+
+var literalKinds = []lexer.Kind{
+	lexer.TLNumber, lexer.TDNumber, lexer.TConstantEncapsedString, lexer.TLine, lexer.TFile,
+	lexer.TDir, lexer.TClassC, lexer.TTraitC, lexer.TMethodC, lexer.TFuncC, lexer.TPropertyC, lexer.TNsC,
+}
+
+var includeKinds = []lexer.Kind{lexer.TInclude, lexer.TIncludeOnce, lexer.TRequire, lexer.TRequireOnce}
+
+var ampersandKinds = []lexer.Kind{lexer.TAmpersandFollowedByVarOrVararg, lexer.TAmpersandNotFollowedByVarOrVararg}
+
+// canStartExpression reports whether the current token can start an expression.
+func (p *parser) canStartExpression() bool {
+	switch p.current().Kind {
+	case lexer.TVariable, lexer.TDollar, lexer.TStatic, lexer.TDoubleQuote, lexer.TBacktick,
+		lexer.TStartHeredoc, lexer.TOpenParen, lexer.TOpenBracket, lexer.TArray, lexer.TList,
+		lexer.TIsset, lexer.TEmpty, lexer.TExit, lexer.TEval, lexer.TFunction, lexer.TFn,
+		lexer.TAttribute, lexer.TMatch, lexer.TNew, lexer.TClone, lexer.TPrint, lexer.TYield,
+		lexer.TYieldFrom, lexer.TThrow, lexer.TExclamationMark, lexer.TDash, lexer.TPlus,
+		lexer.TTilde, lexer.TAt, lexer.TInc, lexer.TDec:
+		return true
+	}
+
+	kind := p.current().Kind
+	return p.isNameStart(0) || isOneOf(kind, literalKinds) || isOneOf(kind, castKinds) || isOneOf(kind, includeKinds)
+}
+
+func (p *parser) parseExpression() *Node {
+	return p.parseBinary(precedenceLowest)
+}
+
+// parseBinary parses an expression whose operators all bind tighter than precedence.
+func (p *parser) parseBinary(precedence int) *Node {
+	left := p.parseUnary()
+
+	for {
+		if p.at(lexer.TQuestion) && precedenceTernary > precedence {
+			n := &Node{Kind: Ternary}
+			n.add(left)
+			p.consume(n)
+			if !p.optional(n, lexer.TColon) {
+				n.add(p.parseExpression())
+				p.expect(n, lexer.TColon)
+			}
+			n.add(p.parseBinary(precedenceTernary))
+			left = n
+			continue
+		}
+
+		operator, ok := binaryOperators[p.current().Kind]
+		if !ok || operator.precedence <= precedence {
+			return left
+		}
+
+		n := &Node{Kind: Binary}
+		n.add(left)
+		p.consume(n)
+		if operator.right {
+			n.add(p.parseBinary(operator.precedence - 1))
+		} else {
+			n.add(p.parseBinary(operator.precedence))
+		}
+		left = n
+	}
+}
+
+func (p *parser) parseUnary() *Node {
+	kind := p.current().Kind
+
+	switch {
+	case kind == lexer.TExclamationMark:
+		return p.parsePrefix(Unary, precedenceNot)
+	case isOneOf(kind, []lexer.Kind{lexer.TDash, lexer.TPlus, lexer.TTilde, lexer.TAt, lexer.TInc, lexer.TDec}):
+		return p.parsePrefix(Unary, precedenceUnary)
+	case isOneOf(kind, castKinds):
+		return p.parsePrefix(Cast, precedenceUnary)
+	case kind == lexer.TClone:
+		return p.parsePrefix(Clone, precedenceUnary)
+	case kind == lexer.TPrint:
+		return p.parsePrefix(Print, precedenceAnd)
+	case kind == lexer.TYieldFrom:
+		return p.parsePrefix(YieldFrom, precedenceAnd)
+	case kind == lexer.TThrow:
+		return p.parsePrefix(Throw, precedenceLowest)
+	case isOneOf(kind, includeKinds):
+		return p.parsePrefix(Include, precedenceAnd)
+	case kind == lexer.TYield:
+		return p.parseYield()
+	case kind == lexer.TNew:
+		n := p.parseNew()
+		if p.supports(lexer.PHP84) && n.Node(Arguments) != nil {
+			return p.parseAssignment(p.parsePostfix(n))
+		}
+		return n
+	}
+
+	return p.parseAssignment(p.parsePostfix(p.parsePrimary()))
+}
+
+// parsePrefix parses a prefix operator and its operand, made of operators binding tighter
+// than precedence.
+func (p *parser) parsePrefix(kind NodeKind, precedence int) *Node {
+	n := &Node{Kind: kind}
+	p.consume(n)
+	n.add(p.parseBinary(precedence))
+	return n
+}
+
+func (p *parser) parseYield() *Node {
+	n := &Node{Kind: Yield}
+	p.consume(n)
+	if !p.canStartExpression() {
+		return n
+	}
+
+	n.add(p.parseBinary(precedenceAnd))
+	if p.optional(n, lexer.TDoubleArrow) {
+		n.add(p.parseBinary(precedenceAnd))
+	}
+	return n
+}
+
+// parseAssignment parses an assignment to target if one follows it. The value binds
+// looser than any operator before the target, so "!$a = f()" assigns f() to $a.
+func (p *parser) parseAssignment(target *Node) *Node {
+	if !p.at(assignmentOperators...) || !isAssignable(target) {
+		return target
+	}
+
+	n := &Node{Kind: Assign}
+	n.add(target)
+	if p.consume(n).Kind == lexer.TAssignment {
+		p.optional(n, ampersandKinds...)
+	}
+	n.add(p.parseBinary(precedenceAnd))
+	return n
+}
+
+func isAssignable(n *Node) bool {
+	switch n.Kind {
+	case Variable, ArrayDimensionFetch, PropertyFetch, StaticPropertyFetch, List, Array:
+		return true
+	}
+	return false
+}
+
+// parsePostfix parses the member accesses, calls, array offsets and increments after
+// expression n.
+func (p *parser) parsePostfix(n *Node) *Node {
+	for {
+		switch p.current().Kind {
+		case lexer.TOpenBracket:
+			fetch := &Node{Kind: ArrayDimensionFetch}
+			fetch.add(n)
+			p.consume(fetch)
+			if !p.at(lexer.TCloseBracket) {
+				fetch.add(p.parseExpression())
+			}
+			p.expect(fetch, lexer.TCloseBracket)
+			n = fetch
+		case lexer.TObjectOperator, lexer.TNullSafeObjectOperator:
+			fetch := &Node{Kind: PropertyFetch}
+			fetch.add(n)
+			p.consume(fetch)
+			p.parseMemberName(fetch)
+			if p.at(lexer.TOpenParen) {
+				fetch.Kind = MethodCall
+				fetch.add(p.parseArguments())
+			}
+			n = fetch
+		case lexer.TPaamayimNekudotayim:
+			n = p.parseStaticMember(n)
+		case lexer.TOpenParen:
+			call := &Node{Kind: Call}
+			call.add(n, p.parseArguments())
+			n = call
+		case lexer.TInc, lexer.TDec:
+			postfix := &Node{Kind: Postfix}
+			postfix.add(n)
+			p.consume(postfix)
+			n = postfix
+		default:
+			return n
+		}
+	}
+}
+
+// parseMemberName parses the name of a property or method after "->": an identifier, a
+// variable or an expression in braces.
+func (p *parser) parseMemberName(n *Node) {
+	switch {
+	case p.at(lexer.TVariable):
+		p.consume(n)
+	case p.at(lexer.TDollar):
+		n.add(p.parseSimpleVariable())
+	case p.at(lexer.TOPENCurly):
+		p.consume(n)
+		n.add(p.parseExpression())
+		p.expect(n, lexer.TCloseCurly)
+	default:
+		p.expectIdentifier(n)
+	}
+}
+
+// parseStaticMember parses what follows "::": a static property, a static call or a class
+// constant, "class" included.
+func (p *parser) parseStaticMember(class *Node) *Node {
+	n := &Node{Kind: ClassConstantFetch}
+	n.add(class)
+	p.consume(n)
+
+	switch {
+	case p.at(lexer.TVariable, lexer.TDollar):
+		n.Kind = StaticPropertyFetch
+		n.add(p.parseSimpleVariable())
+	case p.at(lexer.TOPENCurly):
+		n.Kind = StaticCall
+		p.consume(n)
+		n.add(p.parseExpression())
+		p.expect(n, lexer.TCloseCurly)
+	default:
+		p.expectIdentifier(n)
+	}
+
+	if p.at(lexer.TOpenParen) {
+		n.Kind = StaticCall
+		n.add(p.parseArguments())
+	}
+	return n
+}
+
+// parseSimpleVariable parses $a, $$a or ${expression}.
+func (p *parser) parseSimpleVariable() *Node {
+	n := &Node{Kind: Variable}
+
+	switch {
+	case p.at(lexer.TVariable):
+		p.consume(n)
+	case p.at(lexer.TDollar):
+		p.consume(n)
+		if p.optional(n, lexer.TOPENCurly) {
+			n.add(p.parseExpression())
+			p.expect(n, lexer.TCloseCurly)
+		} else {
+			n.add(p.parseSimpleVariable())
+		}
+	default:
+		p.errorExpecting("variable")
+	}
+	return n
+}
+
+func (p *parser) parsePrimary() *Node {
+	kind := p.current().Kind
+
+	switch {
+	case kind == lexer.TVariable || kind == lexer.TDollar:
+		return p.parseSimpleVariable()
+	case isOneOf(kind, literalKinds):
+		n := &Node{Kind: Literal}
+		p.consume(n)
+		return n
+	case kind == lexer.TStatic:
+		if p.peek(1).Kind == lexer.TFunction || p.peek(1).Kind == lexer.TFn {
+			return p.parseClosureWith(&Node{})
+		}
+		n := &Node{Kind: Name}
+		p.consume(n)
+		return n
+	case p.isNameStart(0):
+		name := p.parseName()
+		switch p.current().Kind {
+		case lexer.TOpenParen, lexer.TPaamayimNekudotayim:
+			return name
+		}
+		n := &Node{Kind: ConstantFetch}
+		n.add(name)
+		return n
+	case kind == lexer.TDoubleQuote:
+		return p.parseInterpolated(InterpolatedString, lexer.TDoubleQuote)
+	case kind == lexer.TBacktick:
+		return p.parseInterpolated(ShellExec, lexer.TBacktick)
+	case kind == lexer.TStartHeredoc:
+		return p.parseInterpolated(Heredoc, lexer.TEndHeredoc)
+	case kind == lexer.TOpenParen:
+		n := &Node{Kind: Paren}
+		p.parseCondition(n)
+		return n
+	case kind == lexer.TOpenBracket:
+		n := &Node{Kind: Array}
+		p.consume(n)
+		p.parseArrayItems(n, lexer.TCloseBracket)
+		return n
+	case kind == lexer.TArray || kind == lexer.TList:
+		n := &Node{Kind: Array}
+		if kind == lexer.TList {
+			n.Kind = List
+		}
+		p.consume(n)
+		if p.expect(n, lexer.TOpenParen) {
+			p.parseArrayItems(n, lexer.TCloseParen)
+		}
+		return n
+	case kind == lexer.TIsset:
+		n := &Node{Kind: Isset}
+		p.consume(n)
+		p.expect(n, lexer.TOpenParen)
+		p.parseExpressionList(n, lexer.TCloseParen)
+		p.expect(n, lexer.TCloseParen)
+		return n
+	case kind == lexer.TEmpty || kind == lexer.TEval:
+		n := &Node{Kind: Empty}
+		if kind == lexer.TEval {
+			n.Kind = Eval
+		}
+		p.consume(n)
+		p.parseCondition(n)
+		return n
+	case kind == lexer.TExit:
+		n := &Node{Kind: Exit}
+		p.consume(n)
+		if p.optional(n, lexer.TOpenParen) {
+			if !p.at(lexer.TCloseParen) {
+				n.add(p.parseExpression())
+			}
+			p.expect(n, lexer.TCloseParen)
+		}
+		return n
+	case kind == lexer.TFunction || kind == lexer.TFn:
+		return p.parseClosureWith(&Node{})
+	case kind == lexer.TAttribute:
+		n := &Node{}
+		p.parseAttributes(n)
+		return p.parseClosureWith(n)
+	case kind == lexer.TMatch:
+		return p.parseMatch()
+	}
+
+	p.error()
+	return &Node{Kind: Error}
+}
+
+// parseArrayItems parses the items of an array or list and the token closing it.
+func (p *parser) parseArrayItems(n *Node, end lexer.Kind) {
+	for !p.at(end) && !p.atEOF() {
+		if p.optional(n, lexer.TComma) {
+			continue
+		}
+
+		item := &Node{Kind: ArrayItem}
+		if p.optional(item, lexer.TEllipsis) {
+			item.add(p.parseExpression())
+		} else if p.optional(item, ampersandKinds...) {
+			item.add(p.parseExpression())
+		} else {
+			item.add(p.parseExpression())
+			if p.optional(item, lexer.TDoubleArrow) {
+				p.optional(item, ampersandKinds...)
+				item.add(p.parseExpression())
+			}
+		}
+		n.add(item)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+	p.expect(n, end)
+}
+
+// parseArguments parses the arguments of a call, named and spread ones included, or the
+// "(...)" of a first-class callable.
+func (p *parser) parseArguments() *Node {
+	n := &Node{Kind: Arguments}
+	p.expect(n, lexer.TOpenParen)
+
+	for !p.at(lexer.TCloseParen) && !p.atEOF() {
+		argument := &Node{Kind: Argument}
+		if p.at(lexer.TEllipsis) && p.peek(1).Kind == lexer.TCloseParen {
+			p.consume(argument)
+			n.add(argument)
+			break
+		}
+
+		if (p.at(lexer.TString) || p.current().Kind.IsKeyword()) && p.peek(1).Kind == lexer.TColon {
+			p.consume(argument)
+			p.consume(argument)
+		}
+		p.optional(argument, lexer.TEllipsis)
+		argument.add(p.parseExpression())
+		n.add(argument)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+
+	p.expect(n, lexer.TCloseParen)
+	return n
+}
+
+// parseNew parses "new" with the class to instantiate: a name, a variable with property
+// fetches, an expression in parentheses or an anonymous class.
+func (p *parser) parseNew() *Node {
+	n := &Node{Kind: New}
+	p.consume(n)
+
+	switch {
+	case p.at(lexer.TClass, lexer.TAttribute, lexer.TReadonly):
+		n.add(p.parseAnonymousClass())
+		return n
+	case p.at(lexer.TStatic):
+		class := &Node{Kind: Name}
+		p.consume(class)
+		n.add(class)
+	case p.at(lexer.TVariable, lexer.TDollar):
+		n.add(p.parseNewClassVariable())
+	case p.at(lexer.TOpenParen):
+		class := &Node{Kind: Paren}
+		p.parseCondition(class)
+		n.add(class)
+	default:
+		n.add(p.parseName())
+	}
+
+	if p.at(lexer.TOpenParen) {
+		n.add(p.parseArguments())
+	}
+	return n
+}
+
+// parseNewClassVariable parses a class given as a variable, which may be followed by
+// property fetches and array offsets but not by calls.
+func (p *parser) parseNewClassVariable() *Node {
+	n := p.parseSimpleVariable()
+
+	for {
+		switch p.current().Kind {
+		case lexer.TObjectOperator, lexer.TNullSafeObjectOperator:
+			fetch := &Node{Kind: PropertyFetch}
+			fetch.add(n)
+			p.consume(fetch)
+			p.parseMemberName(fetch)
+			n = fetch
+		case lexer.TPaamayimNekudotayim:
+			fetch := &Node{Kind: StaticPropertyFetch}
+			fetch.add(n)
+			p.consume(fetch)
+			fetch.add(p.parseSimpleVariable())
+			n = fetch
+		case lexer.TOpenBracket:
+			fetch := &Node{Kind: ArrayDimensionFetch}
+			fetch.add(n)
+			p.consume(fetch)
+			fetch.add(p.parseExpression())
+			p.expect(fetch, lexer.TCloseBracket)
+			n = fetch
+		default:
+			return n
+		}
+	}
+}
+
+func (p *parser) parseAnonymousClass() *Node {
+	n := &Node{Kind: AnonymousClass}
+	p.parseAttributes(n)
+	p.optional(n, lexer.TReadonly)
+	p.expect(n, lexer.TClass)
+
+	if p.at(lexer.TOpenParen) {
+		n.add(p.parseArguments())
+	}
+	if p.at(lexer.TExtends) {
+		n.add(p.parseNameList(Extends))
+	}
+	if p.at(lexer.TImplements) {
+		n.add(p.parseNameList(Implements))
+	}
+
+	n.add(p.parseClassBody())
+	return n
+}
+
+// parseClosureWith parses a closure or an arrow function into n, which may already hold
+// its attributes.
+func (p *parser) parseClosureWith(n *Node) *Node {
+	n.Kind = Closure
+	p.optional(n, lexer.TStatic)
+
+	if p.at(lexer.TFn) {
+		n.Kind = ArrowFunction
+		p.consume(n)
+		p.parseFunctionSignature(n, false)
+		p.expect(n, lexer.TDoubleArrow)
+		n.add(p.parseBinary(precedenceAnd))
+		return n
+	}
+
+	p.expect(n, lexer.TFunction)
+	p.parseFunctionSignature(n, false)
+	n.add(p.parseBlock())
+	return n
+}
+
+func (p *parser) parseClosureUses() *Node {
+	n := &Node{Kind: ClosureUses}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+
+	for p.at(lexer.TVariable, lexer.TAmpersandFollowedByVarOrVararg) {
+		use := &Node{Kind: ClosureUse}
+		p.optional(use, lexer.TAmpersandFollowedByVarOrVararg)
+		p.expect(use, lexer.TVariable)
+		n.add(use)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+
+	p.expect(n, lexer.TCloseParen)
+	return n
+}
+
+func (p *parser) parseMatch() *Node {
+	n := &Node{Kind: Match}
+	p.consume(n)
+	p.parseCondition(n)
+	p.expect(n, lexer.TOPENCurly)
+
+	for !p.at(lexer.TCloseCurly) && !p.atEOF() {
+		arm := &Node{Kind: MatchArm}
+		if p.optional(arm, lexer.TDefault) {
+			p.optional(arm, lexer.TComma)
+		} else {
+			p.parseExpressionList(arm, lexer.TDoubleArrow)
+		}
+		p.expect(arm, lexer.TDoubleArrow)
+		arm.add(p.parseExpression())
+		n.add(arm)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+
+	p.expect(n, lexer.TCloseCurly)
+	return n
+}
+
+// parseInterpolated parses a string with variables in it: a double-quoted string, a shell
+// command in backticks or a heredoc, up to the token of the end kind.
+func (p *parser) parseInterpolated(kind NodeKind, end lexer.Kind) *Node {
+	n := &Node{Kind: kind}
+	p.consume(n)
+
+	for !p.at(end) && !p.atEOF() {
+		switch p.current().Kind {
+		case lexer.TEncapsedAndWhitespace:
+			p.consume(n)
+		case lexer.TVariable:
+			n.add(p.parseInterpolatedVariable())
+		case lexer.TCurlyOpen:
+			p.consume(n)
+			n.add(p.parseExpression())
+			p.expect(n, lexer.TCloseCurly)
+		case lexer.TDollarOpenCurlyBraces:
+			n.add(p.parseDollarBraces())
+		default:
+			n.add(p.skip())
+		}
+	}
+
+	p.expect(n, end)
+	return n
+}
+
+// parseInterpolatedVariable parses a variable in a string, with the single array offset or
+// property fetch php allows after it without braces.
+func (p *parser) parseInterpolatedVariable() *Node {
+	n := p.parseSimpleVariable()
+
+	switch p.current().Kind {
+	case lexer.TOpenBracket:
+		fetch := &Node{Kind: ArrayDimensionFetch}
+		fetch.add(n)
+		p.consume(fetch)
+		offset := &Node{Kind: Literal}
+		switch {
+		case p.at(lexer.TVariable):
+			offset.Kind = Variable
+			p.consume(offset)
+		case p.at(lexer.TDash):
+			offset.Kind = Unary
+			p.consume(offset)
+			p.expect(offset, lexer.TNumString)
+		default:
+			p.optional(offset, lexer.TString, lexer.TNumString)
+		}
+		fetch.add(offset)
+		p.expect(fetch, lexer.TCloseBracket)
+		return fetch
+	case lexer.TObjectOperator, lexer.TNullSafeObjectOperator:
+		fetch := &Node{Kind: PropertyFetch}
+		fetch.add(n)
+		p.consume(fetch)
+		p.expect(fetch, lexer.TString)
+		return fetch
+	}
+	return n
+}
+
+// parseDollarBraces parses "${name}", "${name[offset]}" or "${expression}" in a string.
+func (p *parser) parseDollarBraces() *Node {
+	n := &Node{Kind: Variable}
+	p.consume(n)
+
+	if p.optional(n, lexer.TStringVarName) {
+		if p.optional(n, lexer.TOpenBracket) {
+			n.add(p.parseExpression())
+			p.expect(n, lexer.TCloseBracket)
+		}
+	} else {
+		n.add(p.parseExpression())
+	}
+
+	p.expect(n, lexer.TCloseCurly)
+	return n
+}
@@ -0,0 +1,252 @@
+package parser
+
+// NodeKind is the type of a syntax tree node.
+type NodeKind int
+
+const (
+	Error NodeKind = iota
+	File
+
+	// Statements
+	InlineHTML
+	EmptyStatement
+	ExpressionStatement
+	EchoStatement
+	Block
+	If
+	ElseIf
+	Else
+	While
+	DoWhile
+	For
+	Foreach
+	Switch
+	Case
+	Break
+	Continue
+	Return
+	Global
+	StaticVariables
+	StaticVariable
+	Unset
+	Try
+	Catch
+	Finally
+	Goto
+	Label
+	Declare
+	DeclareItem
+	Namespace
+	Use
+	UseClause
+	ConstStatement
+	ConstElement
+	HaltCompiler
+
+	// Declarations
+	FunctionDeclaration
+	ClassDeclaration
+	InterfaceDeclaration
+	TraitDeclaration
+	EnumDeclaration
+	Extends
+	Implements
+	ClassBody
+	Property
+	PropertyElement
+	ClassConstant
+	Method
+	TraitUse
+	TraitAdaptations
+	TraitAdaptation
+	EnumCase
+	Parameters
+	Parameter
+	AttributeGroup
+	Attribute
+
+	// Types
+	NamedType
+	NullableType
+	UnionType
+	IntersectionType
+
+	// Expressions
+	Name
+	Variable
+	Literal
+	InterpolatedString
+	Heredoc
+	ShellExec
+	ConstantFetch
+	Array
+	ArrayItem
+	List
+	Paren
+	Assign
+	Binary
+	Unary
+	Postfix
+	Cast
+	Ternary
+	Call
+	Arguments
+	Argument
+	New
+	AnonymousClass
+	PropertyFetch
+	MethodCall
+	StaticPropertyFetch
+	StaticCall
+	ClassConstantFetch
+	ArrayDimensionFetch
+	Closure
+	ClosureUses
+	ClosureUse
+	ArrowFunction
+	Match
+	MatchArm
+	Isset
+	Empty
+	Exit
+	Eval
+	Include
+	Print
+	Yield
+	YieldFrom
+	Throw
+	Clone
+
+	// nodeKindCount is the number of kinds; new kinds go above it.
+	nodeKindCount
+)
+
+var nodeKindNames = [...]string{
+	Error:                "Error",
+	File:                 "File",
+	InlineHTML:           "InlineHTML",
+	EmptyStatement:       "EmptyStatement",
+	ExpressionStatement:  "ExpressionStatement",
+	EchoStatement:        "EchoStatement",
+	Block:                "Block",
+	If:                   "If",
+	ElseIf:               "ElseIf",
+	Else:                 "Else",
+	While:                "While",
+	DoWhile:              "DoWhile",
+	For:                  "For",
+	Foreach:              "Foreach",
+	Switch:               "Switch",
+	Case:                 "Case",
+	Break:                "Break",
+	Continue:             "Continue",
+	Return:               "Return",
+	Global:               "Global",
+	StaticVariables:      "StaticVariables",
+	StaticVariable:       "StaticVariable",
+	Unset:                "Unset",
+	Try:                  "Try",
+	Catch:                "Catch",
+	Finally:              "Finally",
+	Goto:                 "Goto",
+	Label:                "Label",
+	Declare:              "Declare",
+	DeclareItem:          "DeclareItem",
+	Namespace:            "Namespace",
+	Use:                  "Use",
+	UseClause:            "UseClause",
+	ConstStatement:       "ConstStatement",
+	ConstElement:         "ConstElement",
+	HaltCompiler:         "HaltCompiler",
+	FunctionDeclaration:  "FunctionDeclaration",
+	ClassDeclaration:     "ClassDeclaration",
+	InterfaceDeclaration: "InterfaceDeclaration",
+	TraitDeclaration:     "TraitDeclaration",
+	EnumDeclaration:      "EnumDeclaration",
+	Extends:              "Extends",
+	Implements:           "Implements",
+	ClassBody:            "ClassBody",
+	Property:             "Property",
+	PropertyElement:      "PropertyElement",
+	ClassConstant:        "ClassConstant",
+	Method:               "Method",
+	TraitUse:             "TraitUse",
+	TraitAdaptations:     "TraitAdaptations",
+	TraitAdaptation:      "TraitAdaptation",
+	EnumCase:             "EnumCase",
+	Parameters:           "Parameters",
+	Parameter:            "Parameter",
+	AttributeGroup:       "AttributeGroup",
+	Attribute:            "Attribute",
+	NamedType:            "NamedType",
+	NullableType:         "NullableType",
+	UnionType:            "UnionType",
+	IntersectionType:     "IntersectionType",
+	Name:                 "Name",
+	Variable:             "Variable",
+	Literal:              "Literal",
+	InterpolatedString:   "InterpolatedString",
+	Heredoc:              "Heredoc",
+	ShellExec:            "ShellExec",
+	ConstantFetch:        "ConstantFetch",
+	Array:                "Array",
+	ArrayItem:            "ArrayItem",
+	List:                 "List",
+	Paren:                "Paren",
+	Assign:               "Assign",
+	Binary:               "Binary",
+	Unary:                "Unary",
+	Postfix:              "Postfix",
+	Cast:                 "Cast",
+	Ternary:              "Ternary",
+	Call:                 "Call",
+	Arguments:            "Arguments",
+	Argument:             "Argument",
+	New:                  "New",
+	AnonymousClass:       "AnonymousClass",
+	PropertyFetch:        "PropertyFetch",
+	MethodCall:           "MethodCall",
+	StaticPropertyFetch:  "StaticPropertyFetch",
+	StaticCall:           "StaticCall",
+	ClassConstantFetch:   "ClassConstantFetch",
+	ArrayDimensionFetch:  "ArrayDimensionFetch",
+	Closure:              "Closure",
+	ClosureUses:          "ClosureUses",
+	ClosureUse:           "ClosureUse",
+	ArrowFunction:        "ArrowFunction",
+	Match:                "Match",
+	MatchArm:             "MatchArm",
+	Isset:                "Isset",
+	Empty:                "Empty",
+	Exit:                 "Exit",
+	Eval:                 "Eval",
+	Include:              "Include",
+	Print:                "Print",
+	Yield:                "Yield",
+	YieldFrom:            "YieldFrom",
+	Throw:                "Throw",
+	Clone:                "Clone",
+}
+
+func (k NodeKind) String() string {
+	if k < 0 || k >= nodeKindCount {
+		return "Unknown"
+	}
+	return nodeKindNames[k]
+}
+
+// IsStatement reports whether nodes of the kind are statements, declarations included.
+func (k NodeKind) IsStatement() bool {
+	return k >= InlineHTML && k <= HaltCompiler || k >= FunctionDeclaration && k <= EnumDeclaration
+}
+
+// IsExpression reports whether nodes of the kind are expressions.
+func (k NodeKind) IsExpression() bool {
+	return k >= Name && k <= Clone && k != ArrayItem && k != Arguments && k != Argument &&
+		k != ClosureUses && k != ClosureUse && k != MatchArm
+}
+
+// IsType reports whether nodes of the kind are type declarations.
+func (k NodeKind) IsType() bool {
+	return k >= NamedType && k <= IntersectionType
+}
@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"slices"
+	"strings"
+)
+
+// Element is a part of the syntax tree: a *Node or a *Token.
+type Element interface {
+	// Pos is the position of the first significant byte, after any leading trivia.
+	Pos() lexer.Position
+	// End is the position right after the last byte.
+	End() lexer.Position
+	// Parent is the node the element is a child of, nil for the root of a tree.
+	Parent() *Node
+
+	setParent(parent *Node)
+	writeTo(builder *strings.Builder)
+}
+
+// Token is a leaf of the tree: a significant token together with the trivia before it, the
+// whitespace, comments and open tags php's parser skips.
+type Token struct {
+	lexer.Token
+	Leading []lexer.Token
+
+	parent *Node
+}
+
+// NewToken returns a token of the kind with the value, for putting in a tree with Rewrite.
+// It has no position and no leading trivia.
+func NewToken(kind lexer.Kind, value string) *Token {
+	return &Token{Token: lexer.Token{Kind: kind, Value: value}}
+}
+
+func (t *Token) Pos() lexer.Position {
+	return t.Start
+}
+
+func (t *Token) End() lexer.Position {
+	return t.Token.End
+}
+
+func (t *Token) Parent() *Node {
+	return t.parent
+}
+
+func (t *Token) setParent(parent *Node) {
+	t.parent = parent
+}
+
+// String returns the source of the token, including its leading trivia.
+func (t *Token) String() string {
+	var builder strings.Builder
+	t.writeTo(&builder)
+	return builder.String()
+}
+
+func (t *Token) writeTo(builder *strings.Builder) {
+	for _, trivia := range t.Leading {
+		builder.WriteString(trivia.Value)
+	}
+	builder.WriteString(t.Value)
+}
+
+// Node is an inner node of the tree. Its children are every node and token it is made of,
+// in source order, so no token of the source is ever left out. What each child is depends
+// on the kind of the node; a child that is missing from the source, because it is optional
+// or because of a syntax error, is simply left out.
+//
+// Children should be changed with Rewrite, which keeps the parent of every element right.
+type Node struct {
+	Kind     NodeKind
+	Children []Element
+
+	parent *Node
+}
+
+// NewNode returns a node of the kind made of the children, which become its own.
+func NewNode(kind NodeKind, children ...Element) *Node {
+	n := &Node{Kind: kind}
+	n.add(children...)
+	return n
+}
+
+// Pos returns the position of the first token of the node, or the zero position if the node
+// has no tokens at all, which only happens for errors.
+func (n *Node) Pos() lexer.Position {
+	if first := n.FirstToken(); first != nil {
+		return first.Pos()
+	}
+	return lexer.Position{}
+}
+
+func (n *Node) End() lexer.Position {
+	if last := n.LastToken(); last != nil {
+		return last.End()
+	}
+	return lexer.Position{}
+}
+
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+func (n *Node) setParent(parent *Node) {
+	n.parent = parent
+}
+
+// String returns the source of the node, including the trivia before its first token.
+// The string of a File node is the whole source it was parsed from.
+func (n *Node) String() string {
+	var builder strings.Builder
+	n.writeTo(&builder)
+	return builder.String()
+}
+
+func (n *Node) writeTo(builder *strings.Builder) {
+	for _, child := range n.Children {
+		child.writeTo(builder)
+	}
+}
+
+// FirstToken returns the first token of the node, or nil if it has none.
+func (n *Node) FirstToken() *Token {
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *Token:
+			return child
+		case *Node:
+			if first := child.FirstToken(); first != nil {
+				return first
+			}
+		}
+	}
+	return nil
+}
+
+// LastToken returns the last token of the node, or nil if it has none.
+func (n *Node) LastToken() *Token {
+	for i := len(n.Children) - 1; i >= 0; i-- {
+		switch child := n.Children[i].(type) {
+		case *Token:
+			return child
+		case *Node:
+			if last := child.LastToken(); last != nil {
+				return last
+			}
+		}
+	}
+	return nil
+}
+
+// Nodes returns the children of the node that are nodes.
+func (n *Node) Nodes() []*Node {
+	var nodes []*Node
+	for _, child := range n.Children {
+		if child, ok := child.(*Node); ok {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+// Tokens returns the children of the node that are tokens.
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, child := range n.Children {
+		if child, ok := child.(*Token); ok {
+			tokens = append(tokens, child)
+		}
+	}
+	return tokens
+}
+
+// Node returns the first child node of one of the given kinds, or nil.
+func (n *Node) Node(kinds ...NodeKind) *Node {
+	for _, child := range n.Children {
+		if child, ok := child.(*Node); ok && slices.Contains(kinds, child.Kind) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Token returns the first child token of one of the given kinds, or nil.
+func (n *Node) Token(kinds ...lexer.Kind) *Token {
+	for _, child := range n.Children {
+		if child, ok := child.(*Token); ok && slices.Contains(kinds, child.Kind) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Text returns the source of the node without any trivia, every token joined directly.
+// It is handy for names, such as the "App\Models" of a namespace.
+func (n *Node) Text() string {
+	var builder strings.Builder
+	var write func(node *Node)
+	write = func(node *Node) {
+		for _, child := range node.Children {
+			switch child := child.(type) {
+			case *Token:
+				builder.WriteString(child.Value)
+			case *Node:
+				write(child)
+			}
+		}
+	}
+	write(n)
+	return builder.String()
+}
+
+func (n *Node) add(elements ...Element) {
+	for _, element := range elements {
+		element.setParent(n)
+	}
+	n.Children = append(n.Children, elements...)
+}
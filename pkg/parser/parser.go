@@ -0,0 +1,265 @@
+// Package parser parses PHP source into a concrete syntax tree.
+//
+// The tree keeps every token of the source, whitespace and comments included, so printing
+// a File node gives back the exact source. Trivia, the whitespace, comments and open tags
+// php's own parser skips, is attached to the significant token that follows it.
+//
+// The parser covers the grammar of PHP 8.3 and never gives up: a syntax error is reported
+// as a diagnostic, with a message worded like php's, and the tokens it couldn't make sense
+// of are kept in Error nodes.
+//
+// Trees are traversed with Walk and Inspect and changed with Rewrite, in the manner of
+// go/ast. Every element knows its parent, and TokenAt and NodeAt find what is at an offset
+// of the source.
+package parser
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/pkg/lexer"
+)
+
+type parser struct {
+	tokens      []*Token
+	pos         int
+	options     lexer.Options
+	diagnostics []lexer.Diagnostic
+	// reported is the index of the token the last syntax error was reported at, so a
+	// single mistake isn't reported again by every rule trying to recover from it.
+	reported int
+}
+
+// Parse lexes and parses source. It returns the File node of the tree and the problems
+// found while lexing and parsing, in the order they were found.
+func Parse(source string, options lexer.Options) (*Node, []lexer.Diagnostic) {
+	tokens, diagnostics := lexer.TokenizeWithOptions(source, options)
+	file, problems := ParseTokens(tokens, options)
+
+	return file, append(diagnostics, problems...)
+}
+
+// ParseTokens parses tokens returned by the lexer, which must end with T_EOF. It returns
+// the File node of the tree and the syntax errors found.
+func ParseTokens(tokens []lexer.Token, options lexer.Options) (*Node, []lexer.Diagnostic) {
+	p := &parser{options: options, reported: -1}
+
+	var leading []lexer.Token
+	for _, token := range tokens {
+		if isTrivia(token.Kind) {
+			leading = append(leading, token)
+			continue
+		}
+
+		p.tokens = append(p.tokens, &Token{Token: token, Leading: leading})
+		leading = nil
+	}
+
+	if len(p.tokens) == 0 || p.tokens[len(p.tokens)-1].Kind != lexer.TEOF {
+		end := lexer.Position{Line: 1, Column: 1}
+		if len(tokens) > 0 {
+			end = tokens[len(tokens)-1].End
+		}
+		p.tokens = append(p.tokens, &Token{Token: lexer.Token{Kind: lexer.TEOF, Start: end, End: end}, Leading: leading})
+	}
+
+	return p.parseFile(), p.diagnostics
+}
+
+// isTrivia reports whether php's parser skips tokens of the kind. Bad characters were
+// already reported by the lexer, so they are skipped too.
+func isTrivia(kind lexer.Kind) bool {
+	return kind.IsTrivia() || kind == lexer.TOpenTag || kind == lexer.TBadCharacter
+}
+
+func (p *parser) current() *Token {
+	return p.tokens[p.pos]
+}
+
+// peek returns the token n tokens after the current one, or the T_EOF token past the end.
+func (p *parser) peek(n int) *Token {
+	return p.tokens[min(p.pos+n, len(p.tokens)-1)]
+}
+
+func (p *parser) at(kinds ...lexer.Kind) bool {
+	return isOneOf(p.current().Kind, kinds)
+}
+
+func (p *parser) atEOF() bool {
+	return p.at(lexer.TEOF)
+}
+
+// next returns the current token and moves past it. It never moves past T_EOF.
+func (p *parser) next() *Token {
+	token := p.current()
+	if token.Kind != lexer.TEOF {
+		p.pos++
+	}
+	return token
+}
+
+// consume adds the current token to n and moves past it. The T_EOF token is left for
+// the File node, which holds the trivia at the end of the source.
+func (p *parser) consume(n *Node) *Token {
+	token := p.next()
+	if token.Kind != lexer.TEOF {
+		n.add(token)
+	}
+	return token
+}
+
+// optional adds the current token to n if it is of one of the kinds.
+func (p *parser) optional(n *Node, kinds ...lexer.Kind) bool {
+	if !p.at(kinds...) {
+		return false
+	}
+
+	p.consume(n)
+	return true
+}
+
+// expect adds the current token to n if it is of the given kind, and reports a syntax
+// error otherwise.
+func (p *parser) expect(n *Node, kind lexer.Kind) bool {
+	if p.optional(n, kind) {
+		return true
+	}
+
+	p.errorExpecting(describeKind(kind))
+	return false
+}
+
+// expectSemicolon adds the token ending a statement to n: a ";", or a "?>" which ends it
+// just the same.
+func (p *parser) expectSemicolon(n *Node) {
+	if !p.optional(n, lexer.TSemiColon, lexer.TCloseTag) {
+		p.errorExpecting(`";"`)
+	}
+}
+
+// error reports a syntax error at the current token.
+func (p *parser) error() {
+	p.errorExpecting("")
+}
+
+func (p *parser) errorExpecting(expected string) {
+	if p.reported == p.pos {
+		return
+	}
+	p.reported = p.pos
+
+	token := p.current()
+	message := "syntax error, unexpected " + describe(token)
+	if expected != "" {
+		message += ", expecting " + expected
+	}
+
+	p.diagnostics = append(p.diagnostics, lexer.Diagnostic{Message: message, Position: token.Start, Snippet: token.Value})
+}
+
+// skip wraps the current token in an Error node, reporting it, so parsing can carry on
+// after it.
+func (p *parser) skip() *Node {
+	p.error()
+
+	n := &Node{Kind: Error}
+	if !p.atEOF() {
+		p.consume(n)
+	}
+	return n
+}
+
+// describe names a token the way php's syntax errors do, like `token ";"` or
+// `variable "$a"`.
+func describe(token *Token) string {
+	switch token.Kind {
+	case lexer.TEOF:
+		return "end of file"
+	case lexer.TString:
+		return fmt.Sprintf("identifier %q", token.Value)
+	case lexer.TVariable:
+		return fmt.Sprintf("variable %q", token.Value)
+	case lexer.TLNumber:
+		return fmt.Sprintf("integer %q", token.Value)
+	case lexer.TDNumber:
+		return fmt.Sprintf("floating-point number %q", token.Value)
+	case lexer.TNameQualified:
+		return fmt.Sprintf("namespaced name %q", token.Value)
+	case lexer.TNameFullyQualified:
+		return fmt.Sprintf("fully qualified name %q", token.Value)
+	case lexer.TNameRelative:
+		return fmt.Sprintf("namespace-relative name %q", token.Value)
+	case lexer.TConstantEncapsedString:
+		if len(token.Value) > 0 && token.Value[0] == '"' {
+			return fmt.Sprintf("double-quoted string %s", token.Value)
+		}
+		return fmt.Sprintf("single-quoted string %q", token.Value[1:max(len(token.Value)-1, 1)])
+	case lexer.TDoubleQuote:
+		return "double-quote mark"
+	case lexer.TInlineHtml:
+		return fmt.Sprintf("inline html %q", token.Value)
+	case lexer.TStartHeredoc:
+		return "heredoc start"
+	case lexer.TEndHeredoc:
+		return "heredoc end"
+	case lexer.TEncapsedAndWhitespace:
+		return fmt.Sprintf("string content %q", token.Value)
+	}
+	return fmt.Sprintf("token %q", token.Value)
+}
+
+// describeKind names what a token of the kind looks like, for "expecting" in syntax errors.
+func describeKind(kind lexer.Kind) string {
+	switch kind {
+	case lexer.TString:
+		return "identifier"
+	case lexer.TVariable:
+		return "variable"
+	}
+
+	if text, ok := kindTexts[kind]; ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return kind.String()
+}
+
+// kindTexts holds the text of the tokens the parser expects by kind.
+var kindTexts = map[lexer.Kind]string{
+	lexer.TSemiColon:           ";",
+	lexer.TColon:               ":",
+	lexer.TComma:               ",",
+	lexer.TOpenParen:           "(",
+	lexer.TCloseParen:          ")",
+	lexer.TOpenBracket:         "[",
+	lexer.TCloseBracket:        "]",
+	lexer.TOPENCurly:           "{",
+	lexer.TCloseCurly:          "}",
+	lexer.TAssignment:          "=",
+	lexer.TDoubleArrow:         "=>",
+	lexer.TAs:                  "as",
+	lexer.TWhile:               "while",
+	lexer.TEndif:               "endif",
+	lexer.TEndWhile:            "endwhile",
+	lexer.TEndFor:              "endfor",
+	lexer.TEndForeach:          "endforeach",
+	lexer.TEndSwitch:           "endswitch",
+	lexer.TEndDeclare:          "enddeclare",
+	lexer.TFunction:            "function",
+	lexer.TPaamayimNekudotayim: "::",
+	lexer.TInsteadof:           "insteadof",
+}
+
+func isOneOf(kind lexer.Kind, kinds []lexer.Kind) bool {
+	for _, k := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) supports(version lexer.Version) bool {
+	current := p.options.Version
+	if current == 0 {
+		current = lexer.LatestVersion
+	}
+	return current >= version
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseIsLossless(t *testing.T) {
+	err := filepath.WalkDir("../../tests_assets", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".php" {
+			return err
+		}
+
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		file, _ := Parse(string(source), lexer.Options{})
+		if file.String() != string(source) {
+			t.Errorf("%s: printing the tree doesn't give back the source", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseSyntax(t *testing.T) {
+	source, err := os.ReadFile("../../tests_assets/parser/syntax.php")
+	if err != nil {
+		t.Fatal("error opening PHP file for testing")
+	}
+
+	file, diagnostics := Parse(string(source), lexer.Options{})
+	for _, diagnostic := range diagnostics {
+		t.Errorf("line %d, column %d: %s", diagnostic.Position.Line, diagnostic.Position.Column, diagnostic.Message)
+	}
+
+	var errors int
+	walk(file, func(n *Node) {
+		if n.Kind == Error {
+			errors++
+		}
+	})
+	if errors > 0 {
+		t.Errorf("expected no error nodes, got %d", errors)
+	}
+}
+
+func TestParseTruncatedSource(t *testing.T) {
+	source, err := os.ReadFile("../../tests_assets/parser/syntax.php")
+	if err != nil {
+		t.Fatal("error opening PHP file for testing")
+	}
+
+	for end := 0; end < len(source); end += 7 {
+		truncated := string(source[:end])
+		file, _ := Parse(truncated, lexer.Options{})
+		if file.String() != truncated {
+			t.Fatalf("source cut at %d: printing the tree doesn't give back the source", end)
+		}
+	}
+}
+
+func TestParseStructure(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "namespace and grouped use",
+			source:   `<?php namespace App; use Foo\{Bar, Baz as Qux};`,
+			expected: `File(Namespace(Name Use(Name UseClause(Name) UseClause(Name))))`,
+		},
+		{
+			name:     "braced namespaces",
+			source:   `<?php namespace A { f(); } namespace { g(); }`,
+			expected: `File(Namespace(Name Block(ExpressionStatement(Call(Name Arguments)))) Namespace(Block(ExpressionStatement(Call(Name Arguments)))))`,
+		},
+		{
+			name:     "class with members",
+			source:   `<?php #[A] final class C extends B implements I { use T; const X = 1; public ?int $a = null; function f(int $x = 1): void {} }`,
+			expected: `File(ClassDeclaration(AttributeGroup(Attribute(Name)) Extends(Name) Implements(Name) ClassBody(TraitUse(Name) ClassConstant(ConstElement(Literal)) Property(NullableType(NamedType(Name)) PropertyElement(ConstantFetch(Name))) Method(Parameters(Parameter(NamedType(Name) Literal)) NamedType(Name) Block))))`,
+		},
+		{
+			name:     "backed enum",
+			source:   `<?php enum E: string { case A = 'a'; }`,
+			expected: `File(EnumDeclaration(NamedType(Name) ClassBody(EnumCase(Literal))))`,
+		},
+		{
+			name:     "closure and arrow function",
+			source:   `<?php $f = static function &($a) use (&$b): int { return 1; }; $g = fn($x) => $x;`,
+			expected: `File(ExpressionStatement(Assign(Variable Closure(Parameters(Parameter) ClosureUses(ClosureUse) NamedType(Name) Block(Return(Literal))))) ExpressionStatement(Assign(Variable ArrowFunction(Parameters(Parameter) Variable))))`,
+		},
+		{
+			name:     "match",
+			source:   `<?php echo match ($a) { 1, 2 => 'x', default => 'y' };`,
+			expected: `File(EchoStatement(Match(Variable MatchArm(Literal Literal Literal) MatchArm(Literal))))`,
+		},
+		{
+			name:     "alternative syntax",
+			source:   `<?php if ($a): foreach ($b as $c): endforeach; else: endif;`,
+			expected: `File(If(Variable Foreach(Variable Variable) Else))`,
+		},
+		{
+			name:     "precedence",
+			source:   `<?php $a = 1 + 2 * 3 ** 2 ?? !$b instanceof C;`,
+			expected: `File(ExpressionStatement(Assign(Variable Binary(Binary(Literal Binary(Literal Binary(Literal Literal))) Unary(Binary(Variable ConstantFetch(Name)))))))`,
+		},
+		{
+			name:     "member access chain",
+			source:   `<?php $a?->b()::C[0]->d;`,
+			expected: `File(ExpressionStatement(PropertyFetch(ArrayDimensionFetch(ClassConstantFetch(MethodCall(Variable Arguments)) Literal))))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, diagnostics := Parse(test.source, lexer.Options{})
+			if len(diagnostics) > 0 {
+				t.Fatalf("unexpected diagnostic: %s", diagnostics[0].Message)
+			}
+
+			if got := shape(file); got != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected string
+		line     int
+	}{
+		{"<?php\n$a = ;", `syntax error, unexpected token ";"`, 2},
+		{"<?php\necho 1\n}", `syntax error, unexpected token "}", expecting ";"`, 3},
+		{"<?php\nfunction 1() {}", `syntax error, unexpected integer "1", expecting identifier`, 2},
+		{"<?php\nclass { }", `syntax error, unexpected token "{", expecting identifier`, 2},
+		{"<?php\nif ($a {", `syntax error, unexpected token "{", expecting ")"`, 2},
+		{"<?php\nfoo(", `syntax error, unexpected end of file, expecting ")"`, 2},
+		{"<?php\n$a = 'x' $b;", `syntax error, unexpected variable "$b", expecting ";"`, 2},
+	}
+
+	for _, test := range tests {
+		file, diagnostics := Parse(test.source, lexer.Options{})
+		if file.String() != test.source {
+			t.Errorf("%q: printing the tree doesn't give back the source", test.source)
+		}
+		if len(diagnostics) == 0 {
+			t.Errorf("%q: expected a syntax error", test.source)
+			continue
+		}
+		if diagnostics[0].Message != test.expected || diagnostics[0].Position.Line != test.line {
+			t.Errorf("%q: expected %q on line %d, got %q on line %d", test.source, test.expected, test.line, diagnostics[0].Message, diagnostics[0].Position.Line)
+		}
+	}
+}
+
+func TestTrivia(t *testing.T) {
+	file, _ := Parse("<?php // comment\n  $a;\n", lexer.Options{})
+
+	variable := file.Node(ExpressionStatement).Node(Variable).FirstToken()
+	if len(variable.Leading) != 3 {
+		t.Fatalf("expected the open tag, comment and whitespace before $a, got %d tokens", len(variable.Leading))
+	}
+	if variable.Pos().Line != 2 || variable.Pos().Column != 3 {
+		t.Errorf("expected $a at 2:3, got %d:%d", variable.Pos().Line, variable.Pos().Column)
+	}
+
+	eof := file.LastToken()
+	if eof.Kind != lexer.TEOF || len(eof.Leading) != 1 || eof.Leading[0].Value != "\n" {
+		t.Errorf("expected the trailing newline before the end of file")
+	}
+}
+
+func TestVersionGating(t *testing.T) {
+	source := "<?php new Foo()->bar();"
+
+	if _, diagnostics := Parse(source, lexer.Options{Version: lexer.PHP84}); len(diagnostics) > 0 {
+		t.Errorf("expected no error for PHP 8.4, got %q", diagnostics[0].Message)
+	}
+	if _, diagnostics := Parse(source, lexer.Options{Version: lexer.PHP83}); len(diagnostics) == 0 {
+		t.Errorf("expected a syntax error for PHP 8.3")
+	}
+}
+
+// shape prints the kinds of the nodes of a tree, leaving tokens out.
+func shape(n *Node) string {
+	var children []string
+	for _, child := range n.Nodes() {
+		children = append(children, shape(child))
+	}
+
+	if len(children) == 0 {
+		return n.Kind.String()
+	}
+	return n.Kind.String() + "(" + strings.Join(children, " ") + ")"
+}
+
+func walk(n *Node, visit func(*Node)) {
+	visit(n)
+	for _, child := range n.Nodes() {
+		walk(child, visit)
+	}
+}
@@ -0,0 +1,58 @@
+package parser
+
+// TokenAt returns the token of the tree under root that covers the byte offset of the
+// source, from its start to its end, or nil if the offset falls on trivia or outside the
+// tree. Offsets are those of the lexer positions the tree was parsed with.
+func TokenAt(root *Node, offset int) *Token {
+	n := root
+	for {
+		var inner *Node
+		for _, child := range n.Children {
+			if !covers(child, offset) {
+				continue
+			}
+
+			switch child := child.(type) {
+			case *Token:
+				return child
+			case *Node:
+				inner = child
+			}
+			break
+		}
+
+		if inner == nil {
+			return nil
+		}
+		n = inner
+	}
+}
+
+// NodeAt returns the innermost node of the tree under root that covers the byte offset of
+// the source, root itself included, or nil if the offset is outside the tree. The nodes
+// around it can be found by following the parents.
+func NodeAt(root *Node, offset int) *Node {
+	if !covers(root, offset) {
+		return nil
+	}
+
+	n := root
+	for {
+		var inner *Node
+		for _, child := range n.Nodes() {
+			if covers(child, offset) {
+				inner = child
+				break
+			}
+		}
+
+		if inner == nil {
+			return n
+		}
+		n = inner
+	}
+}
+
+func covers(element Element, offset int) bool {
+	return element.Pos().Offset <= offset && offset < element.End().Offset
+}
@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"fmt"
+)
+
+// An ApplyFunc is called by Rewrite for each element of a tree, with a cursor on it.
+type ApplyFunc func(cursor *Cursor) bool
+
+// Rewrite traverses a tree in depth-first order, tokens included, and calls pre and post
+// for each element, if they are not nil, before and after its children.
+//
+// If pre returns false, the children of the element and post are skipped for it. If post
+// returns false, the traversal stops and Rewrite returns. The cursor given to pre and post
+// can replace, delete or insert elements around the current one; elements inserted or put
+// in place by pre are traversed, those inserted before the current one or after it aren't.
+//
+// Rewrite returns the root, which pre or post may have replaced. Changes keep the parents
+// right, but not the positions of the tokens, which stay those of the source the tree was
+// parsed from.
+func Rewrite(root *Node, pre, post ApplyFunc) (result *Node) {
+	parent := root.Parent()
+	holder := &Node{Children: []Element{root}}
+
+	defer func() {
+		if r := recover(); r != nil && r != errAbort {
+			panic(r)
+		}
+
+		if len(holder.Children) == 1 {
+			result, _ = holder.Children[0].(*Node)
+		}
+		if result == nil {
+			panic("parser: the root of a rewrite must stay a node")
+		}
+
+		result.setParent(parent)
+		if parent != nil && result != root {
+			for i, child := range parent.Children {
+				if child == root {
+					parent.Children[i] = result
+				}
+			}
+		}
+	}()
+
+	a := &application{pre: pre, post: post, holder: holder}
+	a.apply(holder, &iterator{}, root)
+	return
+}
+
+var errAbort = new(int)
+
+// A Cursor describes the element traversed by Rewrite, with its parent and its index in
+// the children of the parent.
+type Cursor struct {
+	parent  *Node
+	element Element
+	iter    *iterator
+	deleted bool
+	// holder is the node Rewrite puts the root in, so it can be replaced like any element.
+	holder *Node
+}
+
+type iterator struct {
+	index, step int
+}
+
+// Element returns the current element.
+func (c *Cursor) Element() Element {
+	return c.element
+}
+
+// Node returns the current element if it is a node, and nil if it is a token.
+func (c *Cursor) Node() *Node {
+	n, _ := c.element.(*Node)
+	return n
+}
+
+// Token returns the current element if it is a token, and nil if it is a node.
+func (c *Cursor) Token() *Token {
+	t, _ := c.element.(*Token)
+	return t
+}
+
+// Parent returns the node the current element is a child of. It is nil for the root.
+func (c *Cursor) Parent() *Node {
+	if c.parent == c.holder {
+		return nil
+	}
+	return c.parent
+}
+
+// Index returns the index of the current element in the children of its parent.
+func (c *Cursor) Index() int {
+	return c.iter.index
+}
+
+// Replace puts element in place of the current one. The leading trivia of a replaced token
+// goes away with it.
+func (c *Cursor) Replace(element Element) {
+	c.mustBeLive("Replace")
+	if c.element.Parent() == c.parent {
+		c.element.setParent(nil)
+	}
+	element.setParent(c.parent)
+	c.parent.Children[c.iter.index] = element
+	c.element = element
+}
+
+// Delete removes the current element from its parent, along with the trivia before it,
+// which may hold an open tag: move it to the next token to keep it.
+func (c *Cursor) Delete() {
+	c.mustBeLive("Delete")
+	c.element.setParent(nil)
+	c.parent.Children = append(c.parent.Children[:c.iter.index], c.parent.Children[c.iter.index+1:]...)
+	c.iter.step--
+	c.deleted = true
+}
+
+// InsertBefore inserts element before the current one. It is not traversed.
+func (c *Cursor) InsertBefore(element Element) {
+	c.mustBeLive("InsertBefore")
+	element.setParent(c.parent)
+	c.parent.Children = append(c.parent.Children[:c.iter.index], append([]Element{element}, c.parent.Children[c.iter.index:]...)...)
+	c.iter.index++
+}
+
+// InsertAfter inserts element after the current one. It is not traversed.
+func (c *Cursor) InsertAfter(element Element) {
+	c.mustBeLive("InsertAfter")
+	element.setParent(c.parent)
+	index := c.iter.index + 1
+	c.parent.Children = append(c.parent.Children[:index], append([]Element{element}, c.parent.Children[index:]...)...)
+	c.iter.step++
+}
+
+func (c *Cursor) mustBeLive(operation string) {
+	if c.deleted {
+		panic(fmt.Sprintf("parser: %s called on a deleted element", operation))
+	}
+}
+
+type application struct {
+	pre, post ApplyFunc
+	holder    *Node
+	cursor    Cursor
+}
+
+func (a *application) apply(parent *Node, iter *iterator, element Element) {
+	saved := a.cursor
+	defer func() { a.cursor = saved }()
+
+	a.cursor = Cursor{parent: parent, element: element, iter: iter, holder: a.holder}
+	if a.pre != nil && !a.pre(&a.cursor) {
+		return
+	}
+	if a.cursor.deleted {
+		return
+	}
+
+	if n, ok := a.cursor.element.(*Node); ok {
+		a.children(n)
+	}
+
+	if a.post != nil && !a.post(&a.cursor) {
+		panic(errAbort)
+	}
+}
+
+func (a *application) children(n *Node) {
+	iter := &iterator{}
+	for iter.index < len(n.Children) {
+		iter.step = 1
+		a.apply(n, iter, n.Children[iter.index])
+		iter.index += iter.step
+	}
+}
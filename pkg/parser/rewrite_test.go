@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"testing"
+)
+
+func TestRewriteReplace(t *testing.T) {
+	file, _ := Parse("<?php $old = 1; echo $old;", lexer.Options{})
+
+	Rewrite(file, func(cursor *Cursor) bool {
+		if token := cursor.Token(); token != nil && token.Value == "$old" {
+			renamed := NewToken(lexer.TVariable, "$new")
+			renamed.Leading = token.Leading
+			cursor.Replace(renamed)
+		}
+		return true
+	}, nil)
+
+	expected := "<?php $new = 1; echo $new;"
+	if got := file.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestRewriteDeleteAndInsert(t *testing.T) {
+	file, _ := Parse("<?php a(); b(); c();", lexer.Options{})
+
+	Rewrite(file, func(cursor *Cursor) bool {
+		n := cursor.Node()
+		if n == nil || n.Kind != ExpressionStatement {
+			return n != nil
+		}
+
+		switch n.Text() {
+		case "a();":
+			cursor.InsertAfter(statement("after();"))
+		case "b();":
+			cursor.InsertBefore(statement("before();"))
+		case "c();":
+			cursor.Delete()
+		}
+		return false
+	}, nil)
+
+	expected := "<?php a();after();before(); b();"
+	if got := file.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	for _, child := range file.Children {
+		if child.Parent() != file {
+			t.Errorf("expected the statements to keep the file as parent")
+		}
+	}
+}
+
+func TestRewritePostOrderAndAbort(t *testing.T) {
+	file, _ := Parse("<?php a(); b(); c();", lexer.Options{})
+
+	var visited []string
+	Rewrite(file, nil, func(cursor *Cursor) bool {
+		n := cursor.Node()
+		if n != nil && n.Kind == ExpressionStatement {
+			visited = append(visited, n.Text())
+			return n.Text() != "b();"
+		}
+		return true
+	})
+
+	if len(visited) != 2 || visited[0] != "a();" || visited[1] != "b();" {
+		t.Errorf("expected the traversal to stop after b();, visited %v", visited)
+	}
+}
+
+func TestRewriteRoot(t *testing.T) {
+	file, _ := Parse("<?php $sum = $a + $b;", lexer.Options{})
+	assign := file.Node(ExpressionStatement).Node(Assign)
+	binary := assign.Node(Binary)
+
+	result := Rewrite(binary, func(cursor *Cursor) bool {
+		if cursor.Parent() == nil {
+			cursor.Replace(NewNode(Paren, NewToken(lexer.TOpenParen, "("), cursor.Node(), NewToken(lexer.TCloseParen, ")")))
+			return false
+		}
+		return true
+	}, nil)
+
+	if result.Kind != Paren || result.Parent() != assign || assign.Node(Paren) != result {
+		t.Fatalf("expected the parenthesized binary in place of the binary")
+	}
+	if binary.Parent() != result {
+		t.Errorf("expected the binary to be the child of the parentheses")
+	}
+	if got := file.String(); got != "<?php $sum =( $a + $b);" {
+		t.Errorf("unexpected source %q", got)
+	}
+}
+
+func statement(source string) *Node {
+	file, _ := Parse("<?php "+source, lexer.Options{})
+	n := file.Node(ExpressionStatement)
+	n.FirstToken().Leading = nil
+	return n
+}
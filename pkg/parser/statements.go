@@ -0,0 +1,584 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+)
+
+func (p *parser) parseFile() *Node {
+	file := &Node{Kind: File}
+
+	p.parseStatements(file)
+
+	file.add(p.current())
+	return file
+}
+
+// parseTopStatement parses a statement that may also be a namespace, use or const
+// statement, or __halt_compiler.
+func (p *parser) parseTopStatement() *Node {
+	switch p.current().Kind {
+	case lexer.TNamespace:
+		if p.isNamespaceDeclaration() {
+			return p.parseNamespace()
+		}
+	case lexer.TUse:
+		return p.parseUse()
+	case lexer.TConst:
+		return p.parseConstStatement()
+	case lexer.THaltCompiler:
+		return p.parseHaltCompiler()
+	}
+
+	return p.parseStatement()
+}
+
+// parseStatements parses statements until the current token is one of the kinds, or the end
+// of the file.
+func (p *parser) parseStatements(n *Node, until ...lexer.Kind) {
+	for !p.atEOF() && !p.at(until...) {
+		start := p.pos
+		n.add(p.parseTopStatement())
+
+		// A statement that stopped at its very first token would stop us there forever.
+		if p.pos == start {
+			n.add(p.skip())
+		}
+	}
+}
+
+func (p *parser) parseStatement() *Node {
+	switch p.current().Kind {
+	case lexer.TOPENCurly:
+		return p.parseBlock()
+	case lexer.TIf:
+		return p.parseIf()
+	case lexer.TWhile:
+		return p.parseWhile()
+	case lexer.TDo:
+		return p.parseDoWhile()
+	case lexer.TFor:
+		return p.parseFor()
+	case lexer.TForeach:
+		return p.parseForeach()
+	case lexer.TSwitch:
+		return p.parseSwitch()
+	case lexer.TBreak:
+		return p.parseJump(Break)
+	case lexer.TContinue:
+		return p.parseJump(Continue)
+	case lexer.TReturn:
+		return p.parseJump(Return)
+	case lexer.TGlobal:
+		return p.parseGlobal()
+	case lexer.TStatic:
+		if p.peek(1).Kind == lexer.TVariable {
+			return p.parseStaticVariables()
+		}
+	case lexer.TEcho, lexer.TOpenTagWithEcho:
+		return p.parseEcho()
+	case lexer.TUnset:
+		return p.parseUnset()
+	case lexer.TTry:
+		return p.parseTry()
+	case lexer.TGoto:
+		return p.parseGoto()
+	case lexer.TDeclare:
+		return p.parseDeclare()
+	case lexer.TInlineHtml:
+		n := &Node{Kind: InlineHTML}
+		p.consume(n)
+		return n
+	case lexer.TSemiColon, lexer.TCloseTag:
+		n := &Node{Kind: EmptyStatement}
+		p.consume(n)
+		return n
+	case lexer.TString:
+		if p.peek(1).Kind == lexer.TColon {
+			n := &Node{Kind: Label}
+			p.consume(n)
+			p.consume(n)
+			return n
+		}
+	case lexer.TFunction:
+		if p.isFunctionDeclaration(0) {
+			return p.parseFunctionDeclaration(&Node{Kind: FunctionDeclaration})
+		}
+	case lexer.TAbstract, lexer.TFinal, lexer.TReadonly, lexer.TClass, lexer.TInterface, lexer.TTrait, lexer.TEnum:
+		if declaration := p.parseClassLike(&Node{}); declaration != nil {
+			return declaration
+		}
+	case lexer.TAttribute:
+		return p.parseAttributedStatement()
+	case lexer.TCloseCurly, lexer.TEOF:
+		return p.skip()
+	}
+
+	return p.parseExpressionStatement()
+}
+
+func (p *parser) parseExpressionStatement() *Node {
+	n := &Node{Kind: ExpressionStatement}
+	if !p.canStartExpression() {
+		n.Kind = Error
+		p.error()
+		if !p.atEOF() {
+			p.consume(n)
+		}
+		return n
+	}
+
+	n.add(p.parseExpression())
+	p.expectSemicolon(n)
+	return n
+}
+
+// parseAttributedStatement parses a declaration, or a closure, that starts with attributes.
+func (p *parser) parseAttributedStatement() *Node {
+	n := &Node{}
+	p.parseAttributes(n)
+
+	switch {
+	case p.at(lexer.TFunction) && p.isFunctionDeclaration(0):
+		n.Kind = FunctionDeclaration
+		return p.parseFunctionDeclaration(n)
+	case p.at(lexer.TAbstract, lexer.TFinal, lexer.TReadonly, lexer.TClass, lexer.TInterface, lexer.TTrait, lexer.TEnum):
+		if declaration := p.parseClassLike(n); declaration != nil {
+			return declaration
+		}
+	}
+
+	statement := &Node{Kind: ExpressionStatement}
+	statement.add(p.parseClosureWith(n))
+	statement.Children[0] = p.parsePostfix(statement.Children[0].(*Node))
+	p.expectSemicolon(statement)
+	return statement
+}
+
+func (p *parser) parseBlock() *Node {
+	n := &Node{Kind: Block}
+	p.expect(n, lexer.TOPENCurly)
+	p.parseStatements(n, lexer.TCloseCurly)
+	p.expect(n, lexer.TCloseCurly)
+	return n
+}
+
+// parseCondition parses a parenthesized expression, such as the condition of an if.
+func (p *parser) parseCondition(n *Node) {
+	p.expect(n, lexer.TOpenParen)
+	n.add(p.parseExpression())
+	p.expect(n, lexer.TCloseParen)
+}
+
+// parseBody parses the body of a control structure: a statement, or with the alternative
+// syntax a colon and statements up to the end keyword, like endwhile.
+func (p *parser) parseBody(n *Node, end lexer.Kind) {
+	if !p.optional(n, lexer.TColon) {
+		n.add(p.parseStatement())
+		return
+	}
+
+	p.parseStatements(n, end)
+	p.expect(n, end)
+	p.expectSemicolon(n)
+}
+
+func (p *parser) parseIf() *Node {
+	n := &Node{Kind: If}
+	p.consume(n)
+	p.parseCondition(n)
+
+	if !p.at(lexer.TColon) {
+		n.add(p.parseStatement())
+		for p.at(lexer.TElseif, lexer.TElse) {
+			clause := &Node{Kind: ElseIf}
+			if p.at(lexer.TElse) {
+				clause.Kind = Else
+			}
+			p.consume(clause)
+			if clause.Kind == ElseIf {
+				p.parseCondition(clause)
+			}
+			clause.add(p.parseStatement())
+			n.add(clause)
+
+			if clause.Kind == Else {
+				break
+			}
+		}
+		return n
+	}
+
+	p.consume(n)
+	p.parseStatements(n, lexer.TElseif, lexer.TElse, lexer.TEndif)
+	for p.at(lexer.TElseif, lexer.TElse) {
+		clause := &Node{Kind: ElseIf}
+		if p.at(lexer.TElse) {
+			clause.Kind = Else
+		}
+		p.consume(clause)
+		if clause.Kind == ElseIf {
+			p.parseCondition(clause)
+		}
+		p.expect(clause, lexer.TColon)
+		p.parseStatements(clause, lexer.TElseif, lexer.TElse, lexer.TEndif)
+		n.add(clause)
+	}
+
+	p.expect(n, lexer.TEndif)
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseWhile() *Node {
+	n := &Node{Kind: While}
+	p.consume(n)
+	p.parseCondition(n)
+	p.parseBody(n, lexer.TEndWhile)
+	return n
+}
+
+func (p *parser) parseDoWhile() *Node {
+	n := &Node{Kind: DoWhile}
+	p.consume(n)
+	n.add(p.parseStatement())
+	p.expect(n, lexer.TWhile)
+	p.parseCondition(n)
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseFor() *Node {
+	n := &Node{Kind: For}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+
+	for part := 0; part < 3; part++ {
+		end := lexer.TSemiColon
+		if part == 2 {
+			end = lexer.TCloseParen
+		}
+
+		p.parseExpressionList(n, end)
+		p.expect(n, end)
+	}
+
+	p.parseBody(n, lexer.TEndFor)
+	return n
+}
+
+// parseExpressionList parses comma separated expressions up to a token of the end kind,
+// allowing a trailing comma.
+func (p *parser) parseExpressionList(n *Node, end lexer.Kind) {
+	for !p.at(end) && p.canStartExpression() {
+		n.add(p.parseExpression())
+		if !p.optional(n, lexer.TComma) {
+			return
+		}
+	}
+}
+
+func (p *parser) parseForeach() *Node {
+	n := &Node{Kind: Foreach}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+	n.add(p.parseExpression())
+	p.expect(n, lexer.TAs)
+
+	n.add(p.parseForeachTarget())
+	if p.optional(n, lexer.TDoubleArrow) {
+		n.add(p.parseForeachTarget())
+	}
+
+	p.expect(n, lexer.TCloseParen)
+	p.parseBody(n, lexer.TEndForeach)
+	return n
+}
+
+// parseForeachTarget parses the key or value of a foreach, which may be taken by reference.
+func (p *parser) parseForeachTarget() *Node {
+	if !p.at(lexer.TAmpersandFollowedByVarOrVararg, lexer.TAmpersandNotFollowedByVarOrVararg) {
+		return p.parseExpression()
+	}
+
+	n := &Node{Kind: Unary}
+	p.consume(n)
+	n.add(p.parseExpression())
+	return n
+}
+
+func (p *parser) parseSwitch() *Node {
+	n := &Node{Kind: Switch}
+	p.consume(n)
+	p.parseCondition(n)
+
+	alternative := p.optional(n, lexer.TColon)
+	if !alternative {
+		p.expect(n, lexer.TOPENCurly)
+	}
+
+	for p.at(lexer.TCase, lexer.TDefault) {
+		clause := &Node{Kind: Case}
+		if p.consume(clause).Kind == lexer.TCase {
+			clause.add(p.parseExpression())
+		}
+		if !p.optional(clause, lexer.TColon, lexer.TSemiColon) {
+			p.errorExpecting(`":"`)
+		}
+
+		p.parseStatements(clause, lexer.TCase, lexer.TDefault, lexer.TCloseCurly, lexer.TEndSwitch)
+		n.add(clause)
+	}
+
+	if alternative {
+		p.expect(n, lexer.TEndSwitch)
+		p.expectSemicolon(n)
+	} else {
+		p.expect(n, lexer.TCloseCurly)
+	}
+	return n
+}
+
+// parseJump parses break, continue and return, which take an optional expression.
+func (p *parser) parseJump(kind NodeKind) *Node {
+	n := &Node{Kind: kind}
+	p.consume(n)
+	if p.canStartExpression() {
+		n.add(p.parseExpression())
+	}
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseGlobal() *Node {
+	n := &Node{Kind: Global}
+	p.consume(n)
+	for {
+		n.add(p.parseSimpleVariable())
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseStaticVariables() *Node {
+	n := &Node{Kind: StaticVariables}
+	p.consume(n)
+	for {
+		variable := &Node{Kind: StaticVariable}
+		p.expect(variable, lexer.TVariable)
+		if p.optional(variable, lexer.TAssignment) {
+			variable.add(p.parseExpression())
+		}
+		n.add(variable)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseEcho() *Node {
+	n := &Node{Kind: EchoStatement}
+	p.consume(n)
+	for {
+		n.add(p.parseExpression())
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseUnset() *Node {
+	n := &Node{Kind: Unset}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+	p.parseExpressionList(n, lexer.TCloseParen)
+	p.expect(n, lexer.TCloseParen)
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseTry() *Node {
+	n := &Node{Kind: Try}
+	p.consume(n)
+	n.add(p.parseBlock())
+
+	for p.at(lexer.TCatch) {
+		clause := &Node{Kind: Catch}
+		p.consume(clause)
+		p.expect(clause, lexer.TOpenParen)
+		for {
+			clause.add(p.parseName())
+			if !p.optional(clause, lexer.TPipe) {
+				break
+			}
+		}
+		p.optional(clause, lexer.TVariable)
+		p.expect(clause, lexer.TCloseParen)
+		clause.add(p.parseBlock())
+		n.add(clause)
+	}
+
+	if p.at(lexer.TFinally) {
+		clause := &Node{Kind: Finally}
+		p.consume(clause)
+		clause.add(p.parseBlock())
+		n.add(clause)
+	}
+
+	if len(n.Children) == 2 {
+		p.errorExpecting(`"catch"`)
+	}
+	return n
+}
+
+func (p *parser) parseGoto() *Node {
+	n := &Node{Kind: Goto}
+	p.consume(n)
+	p.expect(n, lexer.TString)
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseDeclare() *Node {
+	n := &Node{Kind: Declare}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+	for p.at(lexer.TString) {
+		item := &Node{Kind: DeclareItem}
+		p.consume(item)
+		p.expect(item, lexer.TAssignment)
+		item.add(p.parseExpression())
+		n.add(item)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+	}
+	p.expect(n, lexer.TCloseParen)
+
+	if !p.optional(n, lexer.TSemiColon, lexer.TCloseTag) {
+		p.parseBody(n, lexer.TEndDeclare)
+	}
+	return n
+}
+
+// parseNamespace parses a namespace declaration. With braces the statements of the
+// namespace are in its block; without, they are children of the namespace node itself, up
+// to the next namespace declaration.
+func (p *parser) parseNamespace() *Node {
+	n := &Node{Kind: Namespace}
+	p.consume(n)
+	if !p.at(lexer.TOPENCurly) {
+		n.add(p.parseName())
+	}
+
+	if p.at(lexer.TOPENCurly) {
+		n.add(p.parseBlock())
+		return n
+	}
+
+	p.expectSemicolon(n)
+	for !p.atEOF() && !p.isNamespaceDeclaration() {
+		n.add(p.parseTopStatement())
+	}
+	return n
+}
+
+// isNamespaceDeclaration reports whether the current token starts a namespace declaration,
+// rather than a name relative to the current namespace such as namespaceoo().
+func (p *parser) isNamespaceDeclaration() bool {
+	return p.at(lexer.TNamespace) && p.peek(1).Kind != lexer.TNsSeparator
+}
+
+// parseUse parses a use statement, which imports classes, functions or constants, possibly
+// as a group such as "use App\{Foo, Bar as Baz};".
+func (p *parser) parseUse() *Node {
+	n := &Node{Kind: Use}
+	p.consume(n)
+	p.optional(n, lexer.TFunction, lexer.TConst)
+
+	prefix := p.parseName()
+	if p.at(lexer.TNsSeparator) && p.peek(1).Kind == lexer.TOPENCurly || p.at(lexer.TOPENCurly) {
+		n.add(prefix)
+		p.optional(n, lexer.TNsSeparator)
+		p.consume(n)
+
+		for !p.at(lexer.TCloseCurly) && !p.atEOF() {
+			clause := &Node{Kind: UseClause}
+			p.optional(clause, lexer.TFunction, lexer.TConst)
+			p.parseUseClause(clause, p.parseName())
+			n.add(clause)
+
+			if !p.optional(n, lexer.TComma) {
+				break
+			}
+		}
+
+		p.expect(n, lexer.TCloseCurly)
+		p.expectSemicolon(n)
+		return n
+	}
+
+	for {
+		clause := &Node{Kind: UseClause}
+		p.parseUseClause(clause, prefix)
+		n.add(clause)
+
+		if !p.optional(n, lexer.TComma) {
+			break
+		}
+		prefix = p.parseName()
+	}
+
+	p.expectSemicolon(n)
+	return n
+}
+
+func (p *parser) parseUseClause(clause *Node, name *Node) {
+	clause.add(name)
+	if p.optional(clause, lexer.TAs) {
+		p.expectIdentifier(clause)
+	}
+}
+
+func (p *parser) parseConstStatement() *Node {
+	n := &Node{Kind: ConstStatement}
+	p.consume(n)
+	p.parseConstElements(n)
+	p.expectSemicolon(n)
+	return n
+}
+
+// parseConstElements parses the comma separated "NAME = value" pairs of a const statement
+// or class constant.
+func (p *parser) parseConstElements(n *Node) {
+	for {
+		element := &Node{Kind: ConstElement}
+		p.expectIdentifier(element)
+		p.expect(element, lexer.TAssignment)
+		element.add(p.parseExpression())
+		n.add(element)
+
+		if !p.optional(n, lexer.TComma) {
+			return
+		}
+	}
+}
+
+// parseHaltCompiler parses __halt_compiler and the raw data after it, if any.
+func (p *parser) parseHaltCompiler() *Node {
+	n := &Node{Kind: HaltCompiler}
+	p.consume(n)
+	p.expect(n, lexer.TOpenParen)
+	p.expect(n, lexer.TCloseParen)
+	p.expectSemicolon(n)
+	p.optional(n, lexer.TInlineHtml)
+	return n
+}
@@ -0,0 +1,39 @@
+package parser
+
+// A Visitor's Visit method is called by Walk for every element of a tree. If the visitor w
+// it returns is not nil, Walk visits each child of the element with w, then calls
+// w.Visit(nil).
+type Visitor interface {
+	Visit(element Element) (w Visitor)
+}
+
+// Walk traverses a tree in depth-first order, tokens included: it calls v.Visit(element)
+// and, unless that returns nil, walks the children of element with the visitor returned.
+func Walk(v Visitor, element Element) {
+	if v = v.Visit(element); v == nil {
+		return
+	}
+
+	if n, ok := element.(*Node); ok {
+		for _, child := range n.Children {
+			Walk(v, child)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+type inspector func(Element) bool
+
+func (f inspector) Visit(element Element) Visitor {
+	if f(element) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a tree in depth-first order: it calls f(element) and, if that returns
+// true, inspects the children of element, then calls f(nil).
+func Inspect(element Element, f func(Element) bool) {
+	Walk(inspector(f), element)
+}
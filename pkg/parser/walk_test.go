@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	file, _ := Parse("<?php $a = $b + 1;", lexer.Options{})
+
+	var kinds []string
+	Inspect(file, func(element Element) bool {
+		if n, ok := element.(*Node); ok {
+			kinds = append(kinds, n.Kind.String())
+			return n.Kind != Binary
+		}
+		return true
+	})
+
+	expected := "File ExpressionStatement Assign Variable Binary"
+	if got := strings.Join(kinds, " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+type depthCounter struct {
+	depth, max *int
+}
+
+func (v depthCounter) Visit(element Element) Visitor {
+	if element == nil {
+		*v.depth--
+		return nil
+	}
+
+	*v.depth++
+	*v.max = max(*v.max, *v.depth)
+	return v
+}
+
+func TestWalk(t *testing.T) {
+	file, _ := Parse("<?php f(g($a));", lexer.Options{})
+
+	var depth, deepest int
+	Walk(depthCounter{&depth, &deepest}, file)
+
+	if depth != 0 {
+		t.Errorf("expected every visit to be closed, %d are left", depth)
+	}
+	// File, ExpressionStatement, Call, Arguments, Argument, Call, Arguments, Argument,
+	// Variable and the $a token.
+	if deepest != 10 {
+		t.Errorf("expected a depth of 10, got %d", deepest)
+	}
+}
+
+func TestParents(t *testing.T) {
+	file, _ := Parse("<?php class A { function f() { return [1, 2]; } }", lexer.Options{})
+
+	if file.Parent() != nil {
+		t.Errorf("expected the file to have no parent")
+	}
+
+	Inspect(file, func(element Element) bool {
+		if n, ok := element.(*Node); ok {
+			for _, child := range n.Children {
+				if child.Parent() != n {
+					t.Errorf("wrong parent for a child of %s", n.Kind)
+				}
+			}
+		}
+		return true
+	})
+}
+
+func TestPositionLookup(t *testing.T) {
+	source := "<?php\nfunction f() {\n    return $value;\n}\n"
+	file, _ := Parse(source, lexer.Options{})
+
+	offset := strings.Index(source, "alue")
+	token := TokenAt(file, offset)
+	if token == nil || token.Value != "$value" {
+		t.Fatalf("expected $value at %d, got %v", offset, token)
+	}
+	if token.Parent().Kind != Variable || token.Parent().Parent().Kind != Return {
+		t.Errorf("expected $value to be a variable in a return statement")
+	}
+
+	if n := NodeAt(file, offset); n != token.Parent() {
+		t.Errorf("expected the variable node at %d, got %v", offset, n)
+	}
+
+	// The indentation before "return" is trivia, covered by the block but by no token.
+	offset = strings.Index(source, "    return")
+	if token := TokenAt(file, offset); token != nil {
+		t.Errorf("expected no token on trivia, got %q", token.Value)
+	}
+	if n := NodeAt(file, offset); n == nil || n.Kind != Block {
+		t.Errorf("expected the block around the indentation, got %v", n)
+	}
+
+	if n := NodeAt(file, len(source)+1); n != nil {
+		t.Errorf("expected no node past the end, got %s", n.Kind)
+	}
+}
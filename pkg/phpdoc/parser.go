@@ -0,0 +1,526 @@
+package phpdoc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseType parses a whole type expression, like "array<int, string>|null".
+func ParseType(text string) (Type, error) {
+	p := newTypeParser(text)
+
+	t, err := p.parse()
+	if err == nil && p.current().kind != tokenEnd {
+		err = p.unexpected("end of type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// parseTypePrefix parses the type text starts with, returning it with the offset of its
+// end, and leaving the description that follows it alone.
+func parseTypePrefix(text string) (Type, int, error) {
+	p := newTypeParser(text)
+
+	t, err := p.parse()
+	if err != nil {
+		return nil, 0, err
+	}
+	return t, p.end(), nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenIdentifier
+	tokenVariable
+	tokenNumber
+	tokenString
+	tokenPunctuation
+	tokenInvalid
+)
+
+type typeToken struct {
+	kind       tokenKind
+	value      string
+	start, end int
+	// spaced is set when whitespace comes before the token.
+	spaced bool
+}
+
+// typeParser is a recursive descent parser over the tokens of a type expression. Errors
+// are raised by panicking with a *typeError, recovered by parse.
+type typeParser struct {
+	tokens []typeToken
+	pos    int
+}
+
+// typeError is the error a type that can't be parsed gives.
+type typeError struct {
+	message string
+	offset  int
+}
+
+func (e *typeError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.message, e.offset)
+}
+
+func newTypeParser(text string) *typeParser {
+	return &typeParser{tokens: tokenizeType(text)}
+}
+
+func (p *typeParser) parse() (t Type, err error) {
+	err = p.run(func() {
+		t = p.parseType()
+	})
+	return t, err
+}
+
+// run calls parse, returning the error it raises.
+func (p *typeParser) run(parse func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			failure, ok := r.(*typeError)
+			if !ok {
+				panic(r)
+			}
+			err = failure
+		}
+	}()
+
+	parse()
+	return nil
+}
+
+// run parses text with parse, returning the error it raises.
+func run(text string, parse func(p *typeParser)) error {
+	p := newTypeParser(text)
+	return p.run(func() {
+		parse(p)
+	})
+}
+
+// end returns the offset of the end of the last token parsed.
+func (p *typeParser) end() int {
+	if p.pos == 0 {
+		return 0
+	}
+	return p.tokens[p.pos-1].end
+}
+
+func (p *typeParser) current() typeToken {
+	return p.tokens[p.pos]
+}
+
+func (p *typeParser) peek(n int) typeToken {
+	return p.tokens[min(p.pos+n, len(p.tokens)-1)]
+}
+
+func (p *typeParser) next() typeToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+func (t typeToken) isPunctuation(punctuation string) bool {
+	return t.kind == tokenPunctuation && t.value == punctuation
+}
+
+// is reports whether the current token is the punctuation.
+func (p *typeParser) is(punctuation string) bool {
+	return p.current().isPunctuation(punctuation)
+}
+
+// isAttached reports whether the current token is the punctuation, with no whitespace
+// before it.
+func (p *typeParser) isAttached(punctuation string) bool {
+	return p.is(punctuation) && !p.current().spaced
+}
+
+func (p *typeParser) accept(punctuation string) bool {
+	if p.is(punctuation) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *typeParser) expect(punctuation string) {
+	if !p.accept(punctuation) {
+		panic(p.unexpected(fmt.Sprintf("%q", punctuation)))
+	}
+}
+
+func (p *typeParser) unexpected(expecting string) *typeError {
+	t := p.current()
+	found := fmt.Sprintf("%q", t.value)
+	if t.kind == tokenEnd {
+		found = "end of type"
+	}
+	return &typeError{message: fmt.Sprintf("unexpected %s, expecting %s", found, expecting), offset: t.start}
+}
+
+// parseType parses a type: a union or an intersection of atomic types, or a conditional
+// type.
+func (p *typeParser) parseType() Type {
+	if p.accept("?") {
+		return &Nullable{Type: p.parseAtomic()}
+	}
+
+	t := p.parseAtomic()
+	if conditional := p.tryConditional(t); conditional != nil {
+		return conditional
+	}
+	return p.parseCompound(t)
+}
+
+// parseCompound parses the rest of a union or intersection starting with first.
+func (p *typeParser) parseCompound(first Type) Type {
+	switch {
+	case p.is("|"):
+		union := &Union{Types: unionParts(first)}
+		for p.accept("|") {
+			union.Types = append(union.Types, unionParts(p.parseAtomic())...)
+		}
+		return union
+	case p.is("&") && !p.isByReference():
+		intersection := &Intersection{Types: intersectionParts(first)}
+		for p.is("&") && !p.isByReference() {
+			p.next()
+			intersection.Types = append(intersection.Types, intersectionParts(p.parseAtomic())...)
+		}
+		return intersection
+	}
+	return first
+}
+
+// isByReference reports whether the current "&" marks a parameter of a callable signature
+// as taken by reference, rather than joining an intersection.
+func (p *typeParser) isByReference() bool {
+	after := p.peek(1)
+	return after.kind == tokenVariable || after.kind == tokenPunctuation && after.value == "..."
+}
+
+// tryConditional parses a conditional type whose subject is subject, if one follows. It
+// gives up without consuming anything when what follows doesn't make one, since "is" can
+// as well be the first word of a description.
+func (p *typeParser) tryConditional(subject Type) (conditional Type) {
+	if t := p.current(); t.kind != tokenIdentifier || t.value != "is" {
+		return nil
+	}
+	if _, ok := subject.(*Identifier); !ok {
+		return nil
+	}
+
+	start := p.pos
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*typeError); !ok {
+				panic(r)
+			}
+			p.pos, conditional = start, nil
+		}
+	}()
+
+	p.next()
+	c := &Conditional{Subject: subject}
+	if t := p.current(); t.kind == tokenIdentifier && t.value == "not" && p.peek(1).kind != tokenPunctuation {
+		p.next()
+		c.Negated = true
+	}
+
+	c.Target = p.parseCompound(p.parseAtomic())
+	p.expect("?")
+	c.If = p.parseType()
+	p.expect(":")
+	c.Else = p.parseType()
+	return c
+}
+
+// parseAtomic parses a type that isn't a union or an intersection, with the brackets
+// following it.
+func (p *typeParser) parseAtomic() Type {
+	var t Type
+
+	token := p.current()
+	switch {
+	case p.accept("("):
+		t = p.parseType()
+		p.expect(")")
+	case token.kind == tokenVariable:
+		p.next()
+		t = &Identifier{Name: token.value}
+	case token.kind == tokenNumber || token.kind == tokenString:
+		p.next()
+		t = &Literal{Value: token.value}
+	case token.kind == tokenIdentifier:
+		t = p.parseNamed()
+	case p.is("*"):
+		p.next()
+		t = &Identifier{Name: "*"}
+	default:
+		panic(p.unexpected("type"))
+	}
+
+	for p.isAttached("[") {
+		p.next()
+		if p.accept("]") {
+			t = &Array{Type: t}
+			continue
+		}
+
+		access := &OffsetAccess{Type: t, Offset: p.parseType()}
+		p.expect("]")
+		t = access
+	}
+	return t
+}
+
+// parseNamed parses a type starting with a name: a class constant, a shape, a callable
+// signature, a generic type or just the name.
+func (p *typeParser) parseNamed() Type {
+	name := p.next().value
+
+	switch {
+	case p.isAttached("::"):
+		p.next()
+		return &ConstFetch{Class: name, Name: p.parseConstantName()}
+	case p.isAttached("{") && isShape(name):
+		return p.parseShape(name)
+	case p.isAttached("(") && isCallable(name):
+		return p.parseCallable(name)
+	case p.isAttached("<"):
+		return p.parseGeneric(&Identifier{Name: name})
+	}
+	return &Identifier{Name: name}
+}
+
+// parseConstantName parses the name of a class constant, which may have "*" wildcards.
+func (p *typeParser) parseConstantName() string {
+	var name strings.Builder
+	for first := true; ; first = false {
+		t := p.current()
+		if !first && t.spaced || t.kind != tokenIdentifier && !p.is("*") {
+			break
+		}
+		name.WriteString(p.next().value)
+	}
+
+	if name.Len() == 0 {
+		panic(p.unexpected("constant name"))
+	}
+	return name.String()
+}
+
+func (p *typeParser) parseGeneric(base *Identifier) *Generic {
+	p.expect("<")
+
+	generic := &Generic{Type: base}
+	for !p.is(">") {
+		var argument GenericArgument
+		if t := p.current(); t.kind == tokenIdentifier && (t.value == "covariant" || t.value == "contravariant") && p.peek(1).kind == tokenIdentifier {
+			argument.Variance = p.next().value
+		}
+
+		argument.Type = p.parseType()
+		generic.Arguments = append(generic.Arguments, argument)
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(">")
+
+	if len(generic.Arguments) == 0 {
+		panic(&typeError{message: "generic type without arguments", offset: p.tokens[p.pos-1].start})
+	}
+	return generic
+}
+
+func (p *typeParser) parseShape(name string) *Shape {
+	p.expect("{")
+
+	shape := &Shape{Name: name, Sealed: true}
+	for !p.is("}") {
+		if p.accept("...") {
+			shape.Sealed = false
+			if p.isAttached("<") {
+				shape.Rest = p.parseGeneric(&Identifier{Name: name})
+			}
+			p.accept(",")
+			break
+		}
+
+		var item ShapeItem
+		if key := p.current(); key.kind == tokenIdentifier || key.kind == tokenNumber || key.kind == tokenString {
+			after := p.peek(1)
+			optional := after.kind == tokenPunctuation && after.value == "?" && p.peek(2).value == ":" && p.peek(2).kind == tokenPunctuation
+			if optional || after.kind == tokenPunctuation && after.value == ":" {
+				p.next()
+				item.Key, item.Optional = key.value, optional
+				p.accept("?")
+				p.expect(":")
+			}
+		}
+
+		item.Type = p.parseType()
+		shape.Items = append(shape.Items, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect("}")
+	return shape
+}
+
+func (p *typeParser) parseCallable(name string) *Callable {
+	p.expect("(")
+
+	callable := &Callable{Name: name}
+	for !p.is(")") {
+		parameter := CallableParameter{Type: p.parseType()}
+		parameter.ByReference = p.accept("&")
+		parameter.Variadic = p.accept("...")
+		if t := p.current(); t.kind == tokenVariable {
+			parameter.Name = p.next().value
+		}
+		parameter.Optional = p.accept("=")
+
+		callable.Parameters = append(callable.Parameters, parameter)
+		if !p.accept(",") {
+			break
+		}
+	}
+	p.expect(")")
+
+	if p.accept(":") {
+		if p.accept("?") {
+			callable.Return = &Nullable{Type: p.parseAtomic()}
+		} else {
+			callable.Return = p.parseAtomic()
+		}
+	}
+	return callable
+}
+
+// unionParts returns the types of t if it is a union written in parentheses, or t.
+func unionParts(t Type) []Type {
+	if union, ok := t.(*Union); ok {
+		return union.Types
+	}
+	return []Type{t}
+}
+
+// intersectionParts returns the types of t if it is an intersection written in
+// parentheses, or t.
+func intersectionParts(t Type) []Type {
+	if intersection, ok := t.(*Intersection); ok {
+		return intersection.Types
+	}
+	return []Type{t}
+}
+
+func isShape(name string) bool {
+	switch strings.ToLower(name) {
+	case "array", "list", "non-empty-array", "non-empty-list", "object":
+		return true
+	}
+	return false
+}
+
+func isCallable(name string) bool {
+	switch strings.ToLower(strings.TrimPrefix(name, `\`)) {
+	case "callable", "closure", "pure-callable", "pure-closure":
+		return true
+	}
+	return false
+}
+
+// tokenizeType splits a type expression into tokens, ending with a tokenEnd.
+func tokenizeType(text string) []typeToken {
+	var tokens []typeToken
+
+	spaced := false
+	for i := 0; i < len(text); {
+		c := text[i]
+		start := i
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			spaced = true
+			i++
+			continue
+		case c == '$':
+			i = scanWord(text, i+1)
+			tokens = append(tokens, typeToken{kind: tokenVariable, value: text[start:i], start: start, end: i, spaced: spaced})
+		case isDigit(c) || c == '-' && i+1 < len(text) && isDigit(text[i+1]):
+			i = scanWord(text, i+1)
+			if i < len(text) && text[i] == '.' {
+				i = scanWord(text, i+1)
+			}
+			tokens = append(tokens, typeToken{kind: tokenNumber, value: text[start:i], start: start, end: i, spaced: spaced})
+		case isWordStart(c):
+			i = scanWord(text, i)
+			tokens = append(tokens, typeToken{kind: tokenIdentifier, value: text[start:i], start: start, end: i, spaced: spaced})
+		case c == '\'' || c == '"':
+			i = scanQuoted(text, i)
+			tokens = append(tokens, typeToken{kind: tokenString, value: text[start:i], start: start, end: i, spaced: spaced})
+		case strings.IndexByte("|&?<>,()[]{}:=*.", c) != -1:
+			i += punctuationLength(text[i:])
+			tokens = append(tokens, typeToken{kind: tokenPunctuation, value: text[start:i], start: start, end: i, spaced: spaced})
+		default:
+			i++
+			tokens = append(tokens, typeToken{kind: tokenInvalid, value: text[start:i], start: start, end: i, spaced: spaced})
+		}
+		spaced = false
+	}
+
+	return append(tokens, typeToken{kind: tokenEnd, start: len(text), end: len(text), spaced: spaced})
+}
+
+// scanWord returns the end of the name starting at i. Names may have dashes, as in
+// "non-empty-string", and backslashes, as in "\App\Models\User".
+func scanWord(text string, i int) int {
+	for i < len(text) && (isWordStart(text[i]) || isDigit(text[i]) || text[i] == '-' && i+1 < len(text) && isWordStart(text[i+1])) {
+		i++
+	}
+	return i
+}
+
+// scanQuoted returns the end of the quoted string starting at i, or the end of the text if
+// the string isn't closed.
+func scanQuoted(text string, i int) int {
+	quote := text[i]
+	for i++; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+func isWordStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '\\' || c >= 0x80
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// punctuationLength returns the length of the punctuation text starts with.
+func punctuationLength(text string) int {
+	switch {
+	case strings.HasPrefix(text, "..."):
+		return 3
+	case strings.HasPrefix(text, "::"):
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,131 @@
+// Package phpdoc parses docblocks, the "/** */" comments the lexer gives as T_DOC_COMMENT
+// tokens.
+//
+// Parse splits a docblock into its summary, description and tags, and parses the bodies of
+// the tags fixers care about: @param, @return, @var, @throws, @template, @method and
+// @property, along with their "phpstan-" and "psalm-" variants. Their types are parsed by
+// ParseType, which understands the type syntax of PHPStan and Psalm: unions,
+// intersections, generics, array shapes, callable signatures and conditional types.
+//
+// Tags keep the offsets of their text and of their type in the comment, so a fixer can
+// rewrite one of them and leave the rest of the comment as it was.
+package phpdoc
+
+import (
+	"strings"
+)
+
+// Doc is a parsed docblock.
+type Doc struct {
+	// Summary is the first paragraph of the docblock, up to a blank line or a line ending
+	// with a period, and Description the text after it, up to the first tag. Their lines
+	// are joined with "\n", without the leading "*".
+	Summary     string
+	Description string
+	Tags        []*Tag
+}
+
+// TagsOf returns the tags of the kind, such as "param", in order.
+func (d *Doc) TagsOf(kind string) []*Tag {
+	var tags []*Tag
+	for _, tag := range d.Tags {
+		if tag.Kind() == kind {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// line is a line of a docblock without its leading "*", and where it starts in the comment.
+type line struct {
+	text   string
+	offset int
+}
+
+// Parse parses a docblock, given as written with its "/**" and "*/". Parsing never fails:
+// a tag whose body can't be parsed has its Err set, and is otherwise kept as text.
+func Parse(comment string) *Doc {
+	doc := &Doc{}
+	lines := splitLines(comment)
+
+	first := len(lines)
+	for i, l := range lines {
+		if strings.HasPrefix(l.text, "@") {
+			first = i
+			break
+		}
+	}
+
+	doc.Summary, doc.Description = splitSummary(lines[:first])
+
+	for i := first; i < len(lines); {
+		end := i + 1
+		for end < len(lines) && !strings.HasPrefix(lines[end].text, "@") {
+			end++
+		}
+
+		doc.Tags = append(doc.Tags, parseTag(lines[i:end]))
+		i = end
+	}
+
+	return doc
+}
+
+// splitLines returns the lines of the comment without its "/**" and "*/", the indentation
+// and "*" starting them and the whitespace ending them.
+func splitLines(comment string) []line {
+	start, end := 0, len(comment)
+	if strings.HasPrefix(comment, "/**") {
+		start = 3
+	}
+	if strings.HasSuffix(comment[start:], "*/") {
+		end -= 2
+	}
+
+	var lines []line
+	for offset := start; offset <= end; {
+		next := strings.IndexByte(comment[offset:end], '\n')
+		if next == -1 {
+			next = end - offset
+		}
+		text := comment[offset : offset+next]
+
+		trimmed := strings.TrimLeft(text, " \t")
+		if offset != start && strings.HasPrefix(trimmed, "*") {
+			trimmed = trimmed[1:]
+			if strings.HasPrefix(trimmed, " ") || strings.HasPrefix(trimmed, "\t") {
+				trimmed = trimmed[1:]
+			}
+		}
+
+		lines = append(lines, line{
+			text:   strings.TrimRight(trimmed, " \t\r"),
+			offset: offset + len(text) - len(trimmed),
+		})
+		offset += next + 1
+	}
+
+	return lines
+}
+
+// splitSummary splits the lines before the tags into the summary and the description.
+func splitSummary(lines []line) (string, string) {
+	var summary, description []string
+
+	i := 0
+	for i < len(lines) && lines[i].text == "" {
+		i++
+	}
+	for ; i < len(lines) && lines[i].text != ""; i++ {
+		summary = append(summary, lines[i].text)
+		if strings.HasSuffix(lines[i].text, ".") {
+			i++
+			break
+		}
+	}
+	for ; i < len(lines); i++ {
+		description = append(description, lines[i].text)
+	}
+
+	return strings.Join(summary, "\n"), strings.Trim(strings.Join(description, "\n"), "\n")
+}
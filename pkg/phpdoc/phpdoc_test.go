@@ -0,0 +1,274 @@
+package phpdoc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		source, expected string
+	}{
+		{"int", "int"},
+		{`\App\Models\User`, `\App\Models\User`},
+		{"?int", "?int"},
+		{"int | string|null", "int|string|null"},
+		{"(int|string)|null", "int|string|null"},
+		{"Countable&Traversable", "Countable&Traversable"},
+		{"(A&B)|null", "(A&B)|null"},
+		{"int[][]", "int[][]"},
+		{"(int|string)[]", "(int|string)[]"},
+		{"array<int,string>", "array<int, string>"},
+		{"array<array-key, list<non-empty-string>>", "array<array-key, list<non-empty-string>>"},
+		{"Collection<covariant T>", "Collection<covariant T>"},
+		{"int<0, max>", "int<0, max>"},
+		{"int<-1, 1>", "int<-1, 1>"},
+		{"class-string<Foo>", "class-string<Foo>"},
+		{"array{}", "array{}"},
+		{"array{id: int, 'name'?: string, 0: bool}", "array{id: int, 'name'?: string, 0: bool}"},
+		{"list{int, string}", "list{int, string}"},
+		{"array{a: int, ...}", "array{a: int, ...}"},
+		{"array{a: int, ...<string, mixed>}", "array{a: int, ...<string, mixed>}"},
+		{"object{foo: int, bar?: array{baz: string}}", "object{foo: int, bar?: array{baz: string}}"},
+		{"callable", "callable"},
+		{"callable(): void", "callable(): void"},
+		{"callable(int, string=, float...): bool", "callable(int, string=, float...): bool"},
+		{`\Closure(Foo $foo, int &...$rest): ?Bar`, `\Closure(Foo $foo, int &...$rest): ?Bar`},
+		{"(callable(): int)|null", "(callable(): int)|null"},
+		{"callable(): (int|string)", "callable(): (int|string)"},
+		{"$this", "$this"},
+		{"static", "static"},
+		{"'foo'|'bar'|1|2.5", "'foo'|'bar'|1|2.5"},
+		{"Foo::BAR|Foo::BAZ_*|Foo::*", "Foo::BAR|Foo::BAZ_*|Foo::*"},
+		{"T[K]", "T[K]"},
+		{"key-of<T>|value-of<T>", "key-of<T>|value-of<T>"},
+		{"($value is string ? int : float)", "$value is string ? int : float"},
+		{"T is not array ? T : list<T>", "T is not array ? T : list<T>"},
+		{"T is int|float ? number : (T is string ? text : never)", "T is (int|float) ? number : T is string ? text : never"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			parsed, err := ParseType(test.source)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := parsed.String(); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+
+			again, err := ParseType(parsed.String())
+			if err != nil || again.String() != parsed.String() {
+				t.Errorf("printed type %q doesn't parse back to itself: %v", parsed.String(), err)
+			}
+		})
+	}
+}
+
+func TestParseTypeErrors(t *testing.T) {
+	tests := []struct {
+		source, expected string
+	}{
+		{"", `unexpected end of type, expecting type at offset 0`},
+		{"array<int", `unexpected end of type, expecting ">" at offset 9`},
+		{"int|", `unexpected end of type, expecting type at offset 4`},
+		{"array{a: int", `unexpected end of type, expecting "}" at offset 12`},
+		{"callable(int", `unexpected end of type, expecting ")" at offset 12`},
+		{"int string", `unexpected "string", expecting end of type at offset 4`},
+		{"array<>", `generic type without arguments at offset 6`},
+	}
+
+	for _, test := range tests {
+		_, err := ParseType(test.source)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("%q: expected error %q, got %v", test.source, test.expected, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc := Parse(`/**
+     * Finds users by their name.
+     *
+     * The search is case-insensitive
+     * and matches prefixes.
+     *
+     * @param string $name the name, or a prefix of it
+     * @param array<string,
+     *     mixed> $options
+     * @param int &...$ids
+     * @return Collection<int, User>|null the users found
+     * @throws \InvalidArgumentException
+     * @deprecated use search() instead
+     */`)
+
+	if doc.Summary != "Finds users by their name." {
+		t.Errorf("unexpected summary %q", doc.Summary)
+	}
+	if doc.Description != "The search is case-insensitive\nand matches prefixes." {
+		t.Errorf("unexpected description %q", doc.Description)
+	}
+
+	var got []string
+	for _, tag := range doc.Tags {
+		got = append(got, describe(tag))
+	}
+
+	expected := []string{
+		"param string $name: the name, or a prefix of it",
+		"param array<string, mixed> $options: ",
+		"param int &...$ids: ",
+		"return Collection<int, User>|null: the users found",
+		`throws \InvalidArgumentException: `,
+		"deprecated <nil>: use search() instead",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if params := doc.TagsOf("param"); len(params) != 3 || params[2].Variable != "$ids" || !params[2].Variadic || !params[2].ByReference {
+		t.Errorf("unexpected param tags %v", params)
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	tests := []struct {
+		comment, summary, description string
+	}{
+		{"/** Short. */", "Short.", ""},
+		{"/**\n * First line.\n * Second line.\n */", "First line.", "Second line."},
+		{"/**\n * A summary\n * on two lines\n *\n * Text.\n */", "A summary\non two lines", "Text."},
+		{"/**\n * @var int\n */", "", ""},
+	}
+
+	for _, test := range tests {
+		doc := Parse(test.comment)
+		if doc.Summary != test.summary || doc.Description != test.description {
+			t.Errorf("%q: expected %q and %q, got %q and %q", test.comment, test.summary, test.description, doc.Summary, doc.Description)
+		}
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		comment, expected string
+	}{
+		{"/** @var int */", "var int: "},
+		{"/** @var int $count the count */", "var int $count: the count"},
+		{"/** @psalm-var list<int> $ids */", "psalm-var list<int> $ids: "},
+		{"/** @param $untyped */", "param <nil> $untyped: "},
+		{"/** @return $this */", "return $this: "},
+		{"/** @return ($id is int ? User : null) */", "return $id is int ? User : null: "},
+		{"/** @property-read Carbon $created_at */", "property-read Carbon $created_at: "},
+		{"/** @template T */", "template T: "},
+		{"/** @template-covariant T of object = stdClass the item */", "template-covariant T of object = stdClass: the item"},
+		{"/** @method int count() */", "method int count(): "},
+		{"/** @method static Builder query(array $columns = ['*'], bool &$flag = true) Starts a query */", "method static Builder query(array $columns = ['*'], bool &$flag = true): Starts a query"},
+		{"/** @method static find(int|string $id) */", "method static find(int|string $id): "},
+		{"/** @method foo(...$args) */", "method foo(...$args): "},
+		{"/** @ORM\\Column(type=\"string\") */", `ORM\Column <nil>: (type="string")`},
+		{"/** @param int */", "param error missing parameter name: int"},
+		{"/** @return array<int */", `return error unexpected end of type, expecting ">" at offset 9: array<int`},
+	}
+
+	for _, test := range tests {
+		doc := Parse(test.comment)
+		if len(doc.Tags) != 1 {
+			t.Errorf("%q: expected one tag, got %d", test.comment, len(doc.Tags))
+			continue
+		}
+		if got := describe(doc.Tags[0]); got != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.comment, test.expected, got)
+		}
+	}
+}
+
+func TestTagOffsets(t *testing.T) {
+	comment := "/**\n * @param array<int,\n *   string> $map the map\n * @return void\n */"
+	doc := Parse(comment)
+
+	param := doc.Tags[0]
+	if got := comment[param.Start:param.End]; got != "@param array<int,\n *   string> $map the map" {
+		t.Errorf("unexpected tag text %q", got)
+	}
+	if got := comment[param.TypeStart:param.TypeEnd]; got != "array<int,\n *   string>" {
+		t.Errorf("unexpected type text %q", got)
+	}
+
+	// Replacing the type of the second tag keeps the rest of the comment.
+	ret := doc.Tags[1]
+	rewritten := comment[:ret.TypeStart] + "never" + comment[ret.TypeEnd:]
+	if rewritten != "/**\n * @param array<int,\n *   string> $map the map\n * @return never\n */" {
+		t.Errorf("unexpected rewritten comment %q", rewritten)
+	}
+}
+
+// describe prints a tag as "name type [variable]: description".
+func describe(tag *Tag) string {
+	if tag.Err != nil {
+		return fmt.Sprintf("%s error %v: %s", tag.Name, tag.Err, tag.Description)
+	}
+
+	var parts []string
+	switch {
+	case tag.Method != nil:
+		parts = append(parts, describeMethod(tag.Method))
+	case tag.Template != "":
+		parts = append(parts, tag.Template)
+		if tag.Bound != nil {
+			parts = append(parts, "of", tag.Bound.String())
+		}
+		if tag.Default != nil {
+			parts = append(parts, "=", tag.Default.String())
+		}
+	case tag.Type != nil:
+		parts = append(parts, tag.Type.String())
+	default:
+		parts = append(parts, "<nil>")
+	}
+
+	if tag.Variable != "" {
+		variable := tag.Variable
+		if tag.Variadic {
+			variable = "..." + variable
+		}
+		if tag.ByReference {
+			variable = "&" + variable
+		}
+		parts = append(parts, variable)
+	}
+
+	return tag.Name + " " + strings.Join(parts, " ") + ": " + tag.Description
+}
+
+func describeMethod(method *Method) string {
+	var signature strings.Builder
+	if method.Static {
+		signature.WriteString("static ")
+	}
+	if method.Return != nil {
+		signature.WriteString(method.Return.String() + " ")
+	}
+	signature.WriteString(method.Name + "(")
+	for i, parameter := range method.Parameters {
+		if i > 0 {
+			signature.WriteString(", ")
+		}
+		if parameter.Type != nil {
+			signature.WriteString(parameter.Type.String() + " ")
+		}
+		if parameter.ByReference {
+			signature.WriteString("&")
+		}
+		if parameter.Variadic {
+			signature.WriteString("...")
+		}
+		signature.WriteString(parameter.Name)
+		if parameter.Default != "" {
+			signature.WriteString(" = " + parameter.Default)
+		}
+	}
+	signature.WriteString(")")
+	return signature.String()
+}
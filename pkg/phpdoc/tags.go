@@ -0,0 +1,303 @@
+package phpdoc
+
+import (
+	"errors"
+	"strings"
+)
+
+// Tag is a tag of a docblock, like "@param int $id the user id".
+//
+// Which of its fields are set depends on its kind: Type for @param, @return, @var,
+// @throws and @property, Variable for @param, @var and @property, Template, Bound and
+// Default for @template, and Method for @method. The description is what follows, or the
+// whole body for other tags.
+type Tag struct {
+	// Name is the name of the tag as written, without the "@", such as "param" or
+	// "phpstan-return".
+	Name string
+	// Body is the text after the name, its lines joined with "\n".
+	Body string
+	// Start and End are the offsets of the tag in the comment, from its "@" to the end of
+	// its last line.
+	Start, End int
+
+	Type Type
+	// TypeStart and TypeEnd are the offsets of Type in the comment.
+	TypeStart, TypeEnd int
+
+	// Variable is the parameter or property name, with its "$".
+	Variable    string
+	ByReference bool
+	Variadic    bool
+
+	// Template is the name of a template type, Bound the type it is declared "of" and
+	// Default its default type. Both are nil when not given.
+	Template string
+	Bound    Type
+	Default  Type
+
+	Method *Method
+
+	Description string
+	// Err is why the body couldn't be parsed. The body is then kept as the description.
+	Err error
+
+	// segments map offsets of the body to offsets of the comment, one per line.
+	segments []segment
+}
+
+// Method is a method declared by a @method tag.
+type Method struct {
+	Static bool
+	// Return is nil when no return type is given.
+	Return     Type
+	Name       string
+	Parameters []MethodParameter
+}
+
+// MethodParameter is a parameter of a @method tag. Default is its default value as written,
+// or empty.
+type MethodParameter struct {
+	Type        Type
+	ByReference bool
+	Variadic    bool
+	Name        string
+	Default     string
+}
+
+type segment struct {
+	body, comment int
+}
+
+// Kind returns the name of the tag without a "phpstan-", "psalm-" or "phan-" prefix, so
+// "@psalm-param" is a "param" tag as well.
+func (t *Tag) Kind() string {
+	for _, prefix := range []string{"phpstan-", "psalm-", "phan-"} {
+		if kind, found := strings.CutPrefix(t.Name, prefix); found {
+			return kind
+		}
+	}
+	return t.Name
+}
+
+// offset returns the offset in the comment of an offset in the body.
+func (t *Tag) offset(body int) int {
+	s := t.segments[0]
+	for _, next := range t.segments[1:] {
+		if next.body > body {
+			break
+		}
+		s = next
+	}
+	return s.comment + body - s.body
+}
+
+// parseTag parses a tag from its lines, the first of which starts with its "@".
+func parseTag(lines []line) *Tag {
+	for len(lines) > 1 && lines[len(lines)-1].text == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	first := lines[0]
+	name := scanTagName(first.text)
+	t := &Tag{Name: name, Start: first.offset}
+
+	rest := first.text[1+len(name):]
+	trimmed := strings.TrimLeft(rest, " \t")
+
+	var body strings.Builder
+	t.segments = append(t.segments, segment{body: 0, comment: first.offset + len(first.text) - len(trimmed)})
+	body.WriteString(trimmed)
+	for _, l := range lines[1:] {
+		body.WriteString("\n")
+		t.segments = append(t.segments, segment{body: body.Len(), comment: l.offset})
+		body.WriteString(l.text)
+	}
+
+	last := lines[len(lines)-1]
+	t.Body, t.End = body.String(), last.offset+len(last.text)
+
+	t.parseBody()
+	if t.Err != nil {
+		t.Type, t.Bound, t.Default, t.Method = nil, nil, nil, nil
+		t.Variable, t.ByReference, t.Variadic, t.Template = "", false, false, ""
+		t.Description = t.Body
+	}
+	return t
+}
+
+// scanTagName returns the name of the tag text starts with, after its "@". Names may be
+// namespaced, like those of Doctrine annotations.
+func scanTagName(text string) string {
+	end := 1
+	for end < len(text) && (isWordStart(text[end]) || isDigit(text[end]) || text[end] == '-' || text[end] == ':') {
+		end++
+	}
+	return text[1:end]
+}
+
+func (t *Tag) parseBody() {
+	switch t.Kind() {
+	case "param":
+		t.parseParam()
+	case "return", "throws":
+		t.Description = t.Body[t.parseType(0):]
+	case "var", "property", "property-read", "property-write":
+		t.Description = t.Body[t.parseVariable(t.parseType(0), false):]
+	case "template", "template-covariant", "template-contravariant":
+		t.parseTemplate()
+	case "method":
+		t.parseMethod()
+	default:
+		t.Description = t.Body
+	}
+	t.Description = strings.TrimSpace(t.Description)
+}
+
+// parseParam parses "[type] [&][...]$name [description]".
+func (t *Tag) parseParam() {
+	offset := 0
+	if !strings.HasPrefix(t.Body, "$") && !strings.HasPrefix(t.Body, "&") && !strings.HasPrefix(t.Body, "...") {
+		offset = t.parseType(0)
+	}
+	if t.Err == nil {
+		t.Description = t.Body[t.parseVariable(offset, true):]
+	}
+}
+
+// parseType parses the type at the offset of the body and returns the offset of its end.
+func (t *Tag) parseType(offset int) int {
+	parsed, length, err := parseTypePrefix(t.Body[offset:])
+	if err != nil {
+		t.Err = err
+		return offset
+	}
+
+	t.Type = parsed
+	t.TypeStart, t.TypeEnd = t.offset(offset+leadingSpace(t.Body[offset:])), t.offset(offset+length)
+	return offset + length
+}
+
+// parseVariable parses the "[&][...]$name" at the offset of the body, and returns the offset
+// of its end. It is an error for a missing variable that is required.
+func (t *Tag) parseVariable(offset int, required bool) int {
+	if t.Err != nil {
+		return offset
+	}
+
+	rest := t.Body[offset:]
+	i := leadingSpace(rest)
+	byReference := strings.HasPrefix(rest[i:], "&")
+	if byReference {
+		i++
+	}
+	variadic := strings.HasPrefix(rest[i:], "...")
+	if variadic {
+		i += 3
+	}
+
+	if !strings.HasPrefix(rest[i:], "$") {
+		if required {
+			t.Err = errors.New("missing parameter name")
+		}
+		return offset
+	}
+
+	end := scanWord(rest, i+1)
+	t.Variable, t.ByReference, t.Variadic = rest[i:end], byReference, variadic
+	return offset + end
+}
+
+// parseTemplate parses "name [of|as type] [= type] [description]".
+func (t *Tag) parseTemplate() {
+	t.Err = run(t.Body, func(p *typeParser) {
+		if p.current().kind != tokenIdentifier {
+			panic(p.unexpected("template name"))
+		}
+		t.Template = p.next().value
+
+		if bound := p.current(); bound.kind == tokenIdentifier && (bound.value == "of" || bound.value == "as") {
+			p.next()
+			t.Bound = p.parseType()
+		}
+		if p.accept("=") {
+			t.Default = p.parseType()
+		}
+		t.Description = t.Body[p.end():]
+	})
+}
+
+// parseMethod parses "[static] [type] name([parameters]) [description]".
+func (t *Tag) parseMethod() {
+	method := &Method{}
+	t.Err = run(t.Body, func(p *typeParser) {
+		if keyword := p.current(); keyword.kind == tokenIdentifier && keyword.value == "static" && !p.peek(1).isPunctuation("(") {
+			p.next()
+			method.Static = true
+		}
+
+		returnOrName := p.parseType()
+		if name := p.current(); name.kind == tokenIdentifier {
+			p.next()
+			method.Return, method.Name = returnOrName, name.value
+		} else if identifier, ok := returnOrName.(*Identifier); ok {
+			// "@method static foo()" declares a method returning static.
+			method.Name = identifier.Name
+			if method.Static {
+				method.Return, method.Static = &Identifier{Name: "static"}, false
+			}
+		} else {
+			panic(p.unexpected("method name"))
+		}
+
+		p.expect("(")
+		for !p.is(")") {
+			method.Parameters = append(method.Parameters, parseMethodParameter(p, t.Body))
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.expect(")")
+
+		t.Method, t.Description = method, t.Body[p.end():]
+	})
+}
+
+func parseMethodParameter(p *typeParser, body string) MethodParameter {
+	var parameter MethodParameter
+	if current := p.current(); current.kind != tokenVariable && !current.isPunctuation("&") && !current.isPunctuation("...") {
+		parameter.Type = p.parseType()
+	}
+	parameter.ByReference = p.accept("&")
+	parameter.Variadic = p.accept("...")
+
+	if p.current().kind != tokenVariable {
+		panic(p.unexpected("parameter name"))
+	}
+	parameter.Name = p.next().value
+
+	if p.accept("=") {
+		first, depth := p.pos, 0
+		for current := p.current(); current.kind != tokenEnd; current = p.current() {
+			if depth == 0 && (current.isPunctuation(",") || current.isPunctuation(")")) {
+				break
+			}
+			switch current.value {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth--
+			}
+			p.next()
+		}
+		if p.pos == first {
+			panic(p.unexpected("default value"))
+		}
+		parameter.Default = body[p.tokens[first].start:p.end()]
+	}
+	return parameter
+}
+
+func leadingSpace(text string) int {
+	return len(text) - len(strings.TrimLeft(text, " \t\r\n"))
+}
@@ -0,0 +1,235 @@
+package phpdoc
+
+import (
+	"strings"
+)
+
+// Type is a type expression of a docblock, in the syntax PHPStan and Psalm understand.
+// String prints it back in a canonical form: without redundant parentheses, with a space
+// after commas and around the parts of conditional types.
+type Type interface {
+	String() string
+}
+
+// Identifier is a named type: a class, a keyword like "int" or "non-empty-string", "$this",
+// a template name or, as the subject of a conditional type, a parameter like "$value".
+type Identifier struct {
+	Name string
+}
+
+// Literal is a constant type written as a number or a quoted string, quotes included.
+type Literal struct {
+	Value string
+}
+
+// ConstFetch is a class constant used as a type, like "Foo::BAR" or "Foo::STATUS_*".
+type ConstFetch struct {
+	Class string
+	Name  string
+}
+
+// Nullable is a type written with a leading "?".
+type Nullable struct {
+	Type Type
+}
+
+// Union is a type like "int|string".
+type Union struct {
+	Types []Type
+}
+
+// Intersection is a type like "Countable&Traversable".
+type Intersection struct {
+	Types []Type
+}
+
+// Array is a list type written with brackets, like "int[]".
+type Array struct {
+	Type Type
+}
+
+// OffsetAccess is the type of an offset of another type, like "T[K]".
+type OffsetAccess struct {
+	Type   Type
+	Offset Type
+}
+
+// Generic is a type with arguments, like "array<int, string>" or "Collection<covariant T>".
+type Generic struct {
+	Type      *Identifier
+	Arguments []GenericArgument
+}
+
+// GenericArgument is an argument of a generic type. Variance is "covariant",
+// "contravariant" or empty, and a "*" argument is an Identifier.
+type GenericArgument struct {
+	Variance string
+	Type     Type
+}
+
+// Shape is an array or object shape, like "array{id: int, name?: string}".
+type Shape struct {
+	// Name is the type the shape is of, as written, such as "array", "list" or "object".
+	Name  string
+	Items []ShapeItem
+	// Sealed is false when the shape ends with "...", allowing more items, and Rest is the
+	// type of those items when written, like "...<string, mixed>".
+	Sealed bool
+	Rest   *Generic
+}
+
+// ShapeItem is an item of a shape. Key is empty for items without a key, and is written as
+// in the source, quotes included.
+type ShapeItem struct {
+	Key      string
+	Optional bool
+	Type     Type
+}
+
+// Callable is a callable type with its signature, like "callable(int, string=): bool" or
+// "Closure(Foo $foo): void".
+type Callable struct {
+	Name       string
+	Parameters []CallableParameter
+	// Return is nil when the signature has no return type.
+	Return Type
+}
+
+// CallableParameter is a parameter of a callable signature. Name is empty, or includes the
+// "$".
+type CallableParameter struct {
+	Type        Type
+	ByReference bool
+	Variadic    bool
+	Name        string
+	Optional    bool
+}
+
+// Conditional is a conditional type, like "$value is string ? int : float" or
+// "T is array ? list<T> : T".
+type Conditional struct {
+	Subject Type
+	Negated bool
+	Target  Type
+	If      Type
+	Else    Type
+}
+
+func (t *Identifier) String() string { return t.Name }
+
+func (t *Literal) String() string { return t.Value }
+
+func (t *ConstFetch) String() string { return t.Class + "::" + t.Name }
+
+func (t *Nullable) String() string { return "?" + wrap(t.Type) }
+
+func (t *Union) String() string { return join(t.Types, "|") }
+
+func (t *Intersection) String() string { return join(t.Types, "&") }
+
+func (t *Array) String() string { return wrap(t.Type) + "[]" }
+
+func (t *OffsetAccess) String() string {
+	return wrap(t.Type) + "[" + t.Offset.String() + "]"
+}
+
+func (t *Generic) String() string {
+	arguments := make([]string, len(t.Arguments))
+	for i, argument := range t.Arguments {
+		arguments[i] = argument.Type.String()
+		if argument.Variance != "" {
+			arguments[i] = argument.Variance + " " + arguments[i]
+		}
+	}
+	return t.Type.Name + "<" + strings.Join(arguments, ", ") + ">"
+}
+
+func (t *Shape) String() string {
+	items := make([]string, 0, len(t.Items)+1)
+	for _, item := range t.Items {
+		switch {
+		case item.Key == "":
+			items = append(items, item.Type.String())
+		case item.Optional:
+			items = append(items, item.Key+"?: "+item.Type.String())
+		default:
+			items = append(items, item.Key+": "+item.Type.String())
+		}
+	}
+
+	if !t.Sealed {
+		rest := "..."
+		if t.Rest != nil {
+			rest += strings.TrimPrefix(t.Rest.String(), t.Rest.Type.Name)
+		}
+		items = append(items, rest)
+	}
+	return t.Name + "{" + strings.Join(items, ", ") + "}"
+}
+
+func (t *Callable) String() string {
+	parameters := make([]string, len(t.Parameters))
+	for i, parameter := range t.Parameters {
+		parameters[i] = parameter.String()
+	}
+
+	signature := t.Name + "(" + strings.Join(parameters, ", ") + ")"
+	switch t.Return.(type) {
+	case nil:
+	case *Nullable:
+		signature += ": " + t.Return.String()
+	default:
+		signature += ": " + wrap(t.Return)
+	}
+	return signature
+}
+
+func (p CallableParameter) String() string {
+	var parameter strings.Builder
+	parameter.WriteString(p.Type.String())
+
+	if p.ByReference || p.Name != "" {
+		parameter.WriteString(" ")
+	}
+	if p.ByReference {
+		parameter.WriteString("&")
+	}
+	if p.Variadic {
+		parameter.WriteString("...")
+	}
+	parameter.WriteString(p.Name)
+	if p.Optional {
+		parameter.WriteString("=")
+	}
+	return parameter.String()
+}
+
+func (t *Conditional) String() string {
+	is := " is "
+	if t.Negated {
+		is = " is not "
+	}
+	return wrap(t.Subject) + is + wrap(t.Target) + " ? " + t.If.String() + " : " + t.Else.String()
+}
+
+func join(types []Type, separator string) string {
+	parts := make([]string, len(types))
+	for i, t := range types {
+		parts[i] = wrap(t)
+	}
+	return strings.Join(parts, separator)
+}
+
+// wrap prints a type that is part of another, in parentheses when the type is made of
+// parts itself, or would take what follows it as its own.
+func wrap(t Type) string {
+	switch t := t.(type) {
+	case *Union, *Intersection, *Conditional, *Nullable:
+		return "(" + t.String() + ")"
+	case *Callable:
+		if t.Return != nil {
+			return "(" + t.String() + ")"
+		}
+	}
+	return t.String()
+}
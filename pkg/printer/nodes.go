@@ -0,0 +1,452 @@
+package printer
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"slices"
+)
+
+// node lays out n: it asks for the line breaks and indentation between its children, and
+// leaves the spaces on a line to spaced.
+func (p *printer) node(n *parser.Node) {
+	switch n.Kind {
+	case parser.File:
+		p.file(n)
+	case parser.Block, parser.ClassBody:
+		p.block(n)
+	case parser.Namespace:
+		p.namespace(n)
+	case parser.If, parser.ElseIf, parser.Else, parser.While, parser.DoWhile, parser.For, parser.Foreach,
+		parser.Switch, parser.Declare, parser.Try, parser.Catch, parser.Finally:
+		p.control(n)
+	case parser.Case:
+		p.clause(n)
+	case parser.Arguments, parser.Parameters, parser.ClosureUses, parser.Isset:
+		p.list(n, lexer.TOpenParen, lexer.TCloseParen)
+	case parser.Array:
+		if n.Token(lexer.TArray) != nil {
+			p.list(n, lexer.TOpenParen, lexer.TCloseParen)
+		} else {
+			p.list(n, lexer.TOpenBracket, lexer.TCloseBracket)
+		}
+	case parser.List:
+		p.list(n, lexer.TOpenParen, lexer.TCloseParen)
+	case parser.Use, parser.Match:
+		p.list(n, lexer.TOPENCurly, lexer.TCloseCurly)
+	case parser.TraitAdaptations:
+		p.adaptations(n)
+	case parser.Binary, parser.Assign, parser.Ternary, parser.PropertyFetch, parser.MethodCall:
+		p.continued(n)
+	case parser.InterpolatedString, parser.Heredoc, parser.ShellExec:
+		p.verbatimNode(n)
+	default:
+		p.children(n)
+	}
+}
+
+func (p *printer) children(n *parser.Node) {
+	for i, child := range n.Children {
+		if group, ok := child.(*parser.Node); ok && group.Kind == parser.AttributeGroup {
+			p.node(group)
+			p.afterAttributes(n, i)
+			continue
+		}
+		p.element(child)
+	}
+}
+
+// afterAttributes asks for what follows the attribute group at index i of n: a line break
+// if the source has one, as attributes of declarations usually do, and a space otherwise.
+func (p *printer) afterAttributes(n *parser.Node, i int) {
+	if next := firstTokenAfter(n, i); next != nil && hasLineBreak(next) {
+		p.request(newline)
+	} else {
+		p.request(space)
+	}
+}
+
+func (p *printer) file(n *parser.Node) {
+	p.statements(n.Children)
+
+	// The end of file token holds the comments at the end of the file.
+	last := p.prev
+	if eof, ok := n.Children[len(n.Children)-1].(*parser.Token); ok && eof.Kind == lexer.TEOF {
+		p.request(statementBreak)
+		p.token(eof)
+	}
+
+	if (last == nil || last.Kind != lexer.TInlineHtml && last.Kind != lexer.TCloseTag) && !p.lineStart {
+		p.raw(string(p.config.LineEnding))
+	}
+}
+
+// statements prints the statement nodes among elements each on a line of its own, and the
+// other elements as they come.
+func (p *printer) statements(elements []parser.Element) {
+	var previous *parser.Node
+	for _, element := range elements {
+		n, ok := element.(*parser.Node)
+		if !ok {
+			if t := element.(*parser.Token); t.Kind != lexer.TEOF {
+				p.token(t)
+			}
+			continue
+		}
+
+		switch {
+		case previous != nil && previous.Kind == parser.Use && n.Kind != parser.Use:
+			p.request(blankLine)
+		default:
+			p.request(statementBreak)
+		}
+		p.node(n)
+		previous = n
+	}
+}
+
+// block prints a block of statements or a class body, with its opening brace where the
+// configuration puts it for the parent.
+func (p *printer) block(n *parser.Node) {
+	for _, child := range n.Children {
+		t, ok := child.(*parser.Token)
+		switch {
+		case ok && t.Kind == lexer.TOPENCurly:
+			p.openBrace(t, p.braceStyle(n))
+		case ok && t.Kind == lexer.TCloseCurly:
+			p.closeBrace(t)
+		case ok:
+			p.token(t)
+		default:
+			p.request(statementBreak)
+			p.node(child.(*parser.Node))
+		}
+	}
+}
+
+func (p *printer) braceStyle(n *parser.Node) BraceStyle {
+	parent := n.Parent()
+	if parent == nil {
+		return p.config.BlockBraces
+	}
+
+	switch parent.Kind {
+	case parser.ClassDeclaration, parser.InterfaceDeclaration, parser.TraitDeclaration, parser.EnumDeclaration, parser.Namespace:
+		return p.config.ClassBraces
+	case parser.FunctionDeclaration, parser.Method:
+		if parameters := parent.Node(parser.Parameters); parameters != nil && split(parameters, lexer.TOpenParen) {
+			return SameLine
+		}
+		return p.config.FunctionBraces
+	}
+	return p.config.BlockBraces
+}
+
+func (p *printer) openBrace(t *parser.Token, style BraceStyle) {
+	if style == NextLine {
+		p.request(newline)
+	} else {
+		p.request(space)
+	}
+	p.token(t)
+	p.depth++
+}
+
+// closeBrace prints a closing brace on a line of its own. The comments before it are still
+// indented as the statements of the block.
+func (p *printer) closeBrace(t *parser.Token) {
+	p.trivia(t)
+	p.depth--
+	p.request(newline)
+	p.write(t)
+}
+
+// namespace prints a namespace with a blank line after its declaration, as PSR-12 asks,
+// unless it has a block.
+func (p *printer) namespace(n *parser.Node) {
+	declared := false
+	var statements []parser.Element
+	for _, child := range n.Children {
+		if declared {
+			statements = append(statements, child)
+			continue
+		}
+
+		p.element(child)
+		if t, ok := child.(*parser.Token); ok && (t.Kind == lexer.TSemiColon || t.Kind == lexer.TCloseTag) {
+			declared = true
+		}
+	}
+
+	if len(statements) > 0 {
+		p.request(blankLine)
+		p.statements(statements)
+	}
+}
+
+// control prints a control structure or one of its clauses: a block, the statements of the
+// alternative syntax indented up to the end keyword, or a single statement on the next line.
+func (p *printer) control(n *parser.Node) {
+	alternative := false
+	for _, child := range n.Children {
+		switch child := child.(type) {
+		case *parser.Token:
+			switch {
+			case child.Kind == lexer.TColon:
+				p.token(child)
+				p.depth++
+				alternative = true
+			case isEndKeyword(child.Kind):
+				if alternative {
+					p.depth--
+					alternative = false
+				}
+				p.request(newline)
+				p.token(child)
+			case child.Kind == lexer.TWhile && n.Kind == parser.DoWhile:
+				p.afterBlock()
+				p.token(child)
+			case child.Kind == lexer.TOPENCurly:
+				p.openBrace(child, p.config.BlockBraces)
+			case child.Kind == lexer.TCloseCurly:
+				p.closeBrace(child)
+			default:
+				p.token(child)
+			}
+		case *parser.Node:
+			switch {
+			case isClause(child.Kind):
+				if alternative {
+					p.depth--
+					alternative = false
+				}
+				p.afterBlock()
+				p.node(child)
+			case child.Kind == parser.Case:
+				p.request(statementBreak)
+				p.node(child)
+			case child.Kind == parser.Block:
+				p.node(child)
+			case alternative && isStatement(child.Kind):
+				p.request(statementBreak)
+				p.node(child)
+			case n.Kind == parser.Else && child.Kind == parser.If:
+				p.request(space)
+				p.node(child)
+			case isStatement(child.Kind):
+				p.depth++
+				p.request(newline)
+				p.node(child)
+				p.depth--
+			default:
+				p.node(child)
+			}
+		}
+	}
+
+	if alternative {
+		p.depth--
+	}
+}
+
+// afterBlock asks for what goes before a clause such as else or catch: a space after a
+// closing brace, and a line break otherwise.
+func (p *printer) afterBlock() {
+	if p.prev != nil && p.prev.Kind == lexer.TCloseCurly {
+		p.request(space)
+	} else {
+		p.request(newline)
+	}
+}
+
+// clause prints a case of a switch, with its statements indented below it.
+func (p *printer) clause(n *parser.Node) {
+	indented := false
+	for _, child := range n.Children {
+		if statement, ok := child.(*parser.Node); ok && indented {
+			p.request(statementBreak)
+			p.node(statement)
+			continue
+		}
+
+		p.element(child)
+		if t, ok := child.(*parser.Token); ok && (t.Kind == lexer.TColon || t.Kind == lexer.TSemiColon) {
+			p.depth++
+			indented = true
+		}
+	}
+
+	if indented {
+		p.depth--
+	}
+}
+
+// list prints a list between the open and close tokens, such as arguments or array items.
+// It is split one item per line if the source breaks the line after the opening token, and
+// printed on a single line otherwise.
+func (p *printer) list(n *parser.Node, open, close lexer.Kind) {
+	multiline := split(n, open)
+	opened := false
+
+	for i, child := range n.Children {
+		t, isToken := child.(*parser.Token)
+		switch {
+		case isToken && t.Kind == open && !opened:
+			p.token(t)
+			opened = true
+			if multiline {
+				p.depth++
+			}
+		case isToken && t.Kind == close && opened && multiline:
+			p.trivia(t)
+			p.depth--
+			p.request(newline)
+			p.write(t)
+			opened = false
+		case isToken && t.Kind == close && opened:
+			p.token(t)
+			opened = false
+		case isToken:
+			p.token(t)
+		case opened && multiline:
+			p.request(newline)
+			p.node(child.(*parser.Node))
+		default:
+			if group, ok := child.(*parser.Node); ok && group.Kind == parser.AttributeGroup {
+				p.node(group)
+				p.afterAttributes(n, i)
+				continue
+			}
+			p.node(child.(*parser.Node))
+		}
+	}
+}
+
+// adaptations prints the block of adaptations of a trait use, one on each line.
+func (p *printer) adaptations(n *parser.Node) {
+	for _, child := range n.Children {
+		t, ok := child.(*parser.Token)
+		switch {
+		case ok && t.Kind == lexer.TOPENCurly:
+			p.openBrace(t, p.config.BlockBraces)
+		case ok && t.Kind == lexer.TCloseCurly:
+			p.closeBrace(t)
+		case ok:
+			p.token(t)
+		default:
+			p.request(newline)
+			p.node(child.(*parser.Node))
+		}
+	}
+}
+
+// continued prints an expression that may go on over several lines: the source can break
+// the line before or after an operator, or before a "->", and the rest of the expression
+// is then indented once.
+func (p *printer) continued(n *parser.Node) {
+	chain := n.Kind == parser.PropertyFetch || n.Kind == parser.MethodCall
+	indented := false
+	breakLine := func() {
+		if !indented {
+			p.depth++
+			indented = true
+		}
+		p.request(newline)
+	}
+
+	for i, child := range n.Children {
+		t, ok := child.(*parser.Token)
+		operator := ok && i > 0 && (!chain || isObjectOperator(t.Kind))
+		if operator && hasLineBreak(t) {
+			breakLine()
+		}
+
+		p.element(child)
+
+		if operator && !chain && i+1 < len(n.Children) && !isToken(n.Children[i+1]) {
+			if next := firstTokenAfter(n, i); next != nil && hasLineBreak(next) {
+				breakLine()
+			}
+		}
+	}
+
+	if indented {
+		p.depth--
+	}
+}
+
+// verbatimNode prints a string with variables in it exactly as it is, after its first token.
+func (p *printer) verbatimNode(n *parser.Node) {
+	for i, child := range n.Children {
+		if i == 1 {
+			p.verbatim++
+			defer func() { p.verbatim-- }()
+		}
+		p.element(child)
+	}
+}
+
+// split reports whether the source of n breaks the line right after its first token of the
+// open kind.
+func split(n *parser.Node, open lexer.Kind) bool {
+	for i, child := range n.Children {
+		if t, ok := child.(*parser.Token); ok && t.Kind == open {
+			next := firstTokenAfter(n, i)
+			return next != nil && hasLineBreak(next)
+		}
+	}
+	return false
+}
+
+// firstTokenAfter returns the first token after the child at index i of n, within n.
+func firstTokenAfter(n *parser.Node, i int) *parser.Token {
+	for _, child := range n.Children[i+1:] {
+		switch child := child.(type) {
+		case *parser.Token:
+			return child
+		case *parser.Node:
+			if first := child.FirstToken(); first != nil {
+				return first
+			}
+		}
+	}
+	return nil
+}
+
+// hasLineBreak reports whether the source has a line break before t.
+func hasLineBreak(t *parser.Token) bool {
+	return slices.ContainsFunc(t.Leading, func(trivia lexer.Token) bool {
+		return trivia.Kind != lexer.TOpenTag && lineBreaks(trivia.Value) > 0
+	})
+}
+
+func isToken(element parser.Element) bool {
+	_, ok := element.(*parser.Token)
+	return ok
+}
+
+func isObjectOperator(kind lexer.Kind) bool {
+	return kind == lexer.TObjectOperator || kind == lexer.TNullSafeObjectOperator
+}
+
+// isStatement reports whether nodes of the kind stand as statements on their own lines,
+// unlike parts of statements such as clauses.
+func isStatement(kind parser.NodeKind) bool {
+	switch kind {
+	case parser.ElseIf, parser.Else, parser.Case, parser.Catch, parser.Finally, parser.DeclareItem,
+		parser.UseClause, parser.ConstElement, parser.StaticVariable:
+		return false
+	}
+	return kind.IsStatement()
+}
+
+func isClause(kind parser.NodeKind) bool {
+	return kind == parser.ElseIf || kind == parser.Else || kind == parser.Catch || kind == parser.Finally
+}
+
+func isEndKeyword(kind lexer.Kind) bool {
+	switch kind {
+	case lexer.TEndif, lexer.TEndWhile, lexer.TEndFor, lexer.TEndForeach, lexer.TEndSwitch, lexer.TEndDeclare:
+		return true
+	}
+	return false
+}
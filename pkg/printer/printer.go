@@ -0,0 +1,309 @@
+// Package printer prints syntax trees from the parser package back as PHP source.
+//
+// Unlike a fixer, which patches the tokens of a file, the printer lays the whole tree out
+// again: it decides every line break, indentation and space between tokens, by default as
+// PSR-12 asks. Only the choices the source makes that a layout can't, such as blank lines
+// between statements, lists split across lines and comments, are taken from it. Comments
+// stay before the token they were attached to, strings and heredocs are printed as they
+// are, and so is the HTML around PHP tags.
+package printer
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"github.com/byawitz/gint/pkg/source"
+	"io"
+	"strings"
+)
+
+// BraceStyle is where an opening brace goes.
+type BraceStyle int
+
+const (
+	// SameLine puts the brace at the end of the line of the declaration or statement.
+	SameLine BraceStyle = iota
+	// NextLine puts the brace alone on the line after the declaration or statement.
+	NextLine
+)
+
+// Config controls the layout of the printed source.
+type Config struct {
+	// Indent is printed once per level of indentation.
+	Indent string
+	// LineEnding ends every line the printer breaks. Line breaks in strings, heredocs and
+	// inline HTML are printed as they are.
+	LineEnding source.LineEnding
+
+	// ClassBraces is for classes, interfaces, traits, enums and braced namespaces.
+	ClassBraces BraceStyle
+	// FunctionBraces is for functions and methods. When their parameters are split across
+	// lines, the brace always goes on the line of the closing parenthesis.
+	FunctionBraces BraceStyle
+	// BlockBraces is for control structures, closures and anonymous classes.
+	BlockBraces BraceStyle
+}
+
+// PSR12 is the layout of the PSR-12 coding style.
+var PSR12 = Config{
+	Indent:         "    ",
+	LineEnding:     source.LF,
+	ClassBraces:    NextLine,
+	FunctionBraces: NextLine,
+	BlockBraces:    SameLine,
+}
+
+// Fprint prints node to output with the PSR-12 layout.
+func Fprint(output io.Writer, node *parser.Node) error {
+	return PSR12.Fprint(output, node)
+}
+
+// Fprint prints node to output. A File node is printed with a line break at its end,
+// unless it ends with inline HTML, and, when it has no inline HTML at all, with its
+// "<?php" tag alone on the first line.
+func (c *Config) Fprint(output io.Writer, node *parser.Node) error {
+	p := &printer{config: c, lineStart: true, phpOnly: node.Kind == parser.File && !hasInlineHtml(node)}
+	p.node(node)
+
+	_, err := io.WriteString(output, p.output.String())
+	return err
+}
+
+// whitespace is what can be printed between two tokens, from the least to the most.
+type whitespace int
+
+const (
+	noSpace whitespace = iota
+	space
+	newline
+	// statementBreak is a line break before a statement or a member, which keeps a blank
+	// line the source had there.
+	statementBreak
+	blankLine
+)
+
+type printer struct {
+	config *Config
+	output strings.Builder
+	depth  int
+
+	// pending is the whitespace the layout asks for before the next comment or token.
+	pending whitespace
+	// boundary is set from a statement break until the next token, so a blank line in the
+	// source is kept before the comments of a statement as well as before the statement.
+	boundary bool
+	// breaks is the number of line breaks the source has before the next comment or token.
+	breaks int
+	// afterComment and afterTag are set when a comment or an open tag was printed last,
+	// which the source decides what follows.
+	afterComment bool
+	afterTag     bool
+	// verbatim is set inside strings, where tokens are printed as they are.
+	verbatim int
+	// phpOnly is set for files without inline HTML, which get a line break after the open
+	// tag they start with.
+	phpOnly bool
+
+	prev      *parser.Token
+	lineStart bool
+}
+
+// request asks for at least ws before the next comment or token.
+func (p *printer) request(ws whitespace) {
+	p.pending = max(p.pending, ws)
+	if ws >= statementBreak {
+		p.boundary = true
+	}
+}
+
+func (p *printer) element(element parser.Element) {
+	switch element := element.(type) {
+	case *parser.Token:
+		p.token(element)
+	case *parser.Node:
+		p.node(element)
+	}
+}
+
+func (p *printer) token(t *parser.Token) {
+	p.trivia(t)
+	p.write(t)
+}
+
+// trivia prints the comments and open tags before t, dropping the whitespace, but counting
+// the line breaks in it.
+func (p *printer) trivia(t *parser.Token) {
+	if p.verbatim > 0 {
+		for _, trivia := range t.Leading {
+			p.raw(trivia.Value)
+		}
+		return
+	}
+
+	for _, trivia := range t.Leading {
+		switch {
+		case trivia.Kind == lexer.TWhitespace:
+			p.breaks += lineBreaks(trivia.Value)
+		case trivia.Kind == lexer.TOpenTag:
+			first := p.output.Len() == 0
+			p.raw(strings.TrimRight(trivia.Value, " \t\r\n"))
+			p.afterTag, p.afterComment = true, false
+			p.breaks = lineBreaks(trivia.Value)
+			if first && p.phpOnly && strings.EqualFold(p.output.String(), "<?php") {
+				p.breaks = max(p.breaks, 1)
+			}
+		case trivia.Kind.IsComment() && p.breaks == 0 && p.prev != nil && !p.afterTag:
+			// A comment at the end of a line stays there, whatever the layout asks for
+			// after it.
+			pending := p.pending
+			p.separate(p.sourceSpace(noSpace, true))
+			p.pending = pending
+			p.comment(trivia.Value)
+		case trivia.Kind.IsComment():
+			p.separate(p.sourceSpace(p.pending, true))
+			p.comment(trivia.Value)
+		default:
+			p.separate(space)
+			p.raw(trivia.Value)
+		}
+	}
+}
+
+// comment prints a comment. The line break ending a "//" or "#" comment is left to the
+// layout, and the lines of a block comment whose lines start with a "*" are indented again.
+func (p *printer) comment(value string) {
+	breaks := 0
+	if trimmed := strings.TrimRight(value, "\r\n"); trimmed != value {
+		value, breaks = trimmed, 1
+	}
+
+	lines := strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n")
+	aligned := len(lines) > 1
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(strings.TrimLeft(line, " \t"), "*") {
+			aligned = false
+		}
+	}
+
+	if aligned {
+		for i := 1; i < len(lines); i++ {
+			lines[i] = p.indentation() + " " + strings.TrimLeft(lines[i], " \t")
+		}
+		value = strings.Join(lines, string(p.config.LineEnding))
+	}
+
+	p.raw(value)
+	p.afterComment, p.afterTag = true, false
+	p.breaks = breaks
+}
+
+// write prints t after the whitespace it needs.
+func (p *printer) write(t *parser.Token) {
+	if p.verbatim > 0 {
+		p.raw(t.Value)
+		p.prev = t
+		return
+	}
+
+	ws := p.pending
+	if p.prev != nil && spaced(p.prev, t) {
+		ws = max(ws, space)
+	}
+
+	switch {
+	case t.Kind == lexer.TEOF || t.Kind == lexer.TInlineHtml:
+		ws = noSpace
+	case t.Kind == lexer.TCloseTag:
+		ws = newline
+		if p.breaks == 0 {
+			ws = space
+		}
+	default:
+		ws = p.sourceSpace(ws, false)
+	}
+
+	p.separate(ws)
+	p.raw(t.Value)
+	p.prev = t
+	p.afterComment, p.afterTag, p.boundary = false, false, false
+}
+
+// sourceSpace returns the whitespace before the next comment or token, given what the layout
+// asks for. After a comment or an open tag, and before a comment, the line breaks of the
+// source decide, since the layout knows nothing about comments.
+func (p *printer) sourceSpace(ws whitespace, comment bool) whitespace {
+	fromSource := space
+	if p.breaks > 0 {
+		fromSource = newline
+	}
+
+	switch {
+	case p.afterTag:
+		return fromSource
+	case p.afterComment:
+		return max(fromSource, ws)
+	case comment:
+		if ws >= newline {
+			return ws
+		}
+		if p.breaks == 0 && p.prev != nil && (p.prev.Kind == lexer.TOpenParen || p.prev.Kind == lexer.TOpenBracket) {
+			return noSpace
+		}
+		return fromSource
+	}
+	return ws
+}
+
+// separate prints ws, and consumes what the layout asked for.
+func (p *printer) separate(ws whitespace) {
+	blank := ws == blankLine || p.boundary && ws >= newline && p.breaks >= 2
+	if p.prev != nil && p.prev.Kind == lexer.TOPENCurly && !p.afterComment {
+		blank = false
+	}
+	p.pending, p.breaks = noSpace, 0
+
+	if p.output.Len() == 0 || p.prev != nil && (p.prev.Kind == lexer.TInlineHtml || p.prev.Kind == lexer.TCloseTag) && !p.afterTag && !p.afterComment {
+		return
+	}
+
+	switch {
+	case ws >= newline:
+		if !p.lineStart {
+			p.raw(string(p.config.LineEnding))
+		}
+		if blank {
+			p.raw(string(p.config.LineEnding))
+		}
+		p.raw(p.indentation())
+	case ws == space && !p.lineStart:
+		p.raw(" ")
+	}
+}
+
+func (p *printer) raw(text string) {
+	if text == "" {
+		return
+	}
+
+	p.output.WriteString(text)
+	p.lineStart = strings.HasSuffix(text, "\n") || strings.HasSuffix(text, "\r")
+}
+
+func (p *printer) indentation() string {
+	return strings.Repeat(p.config.Indent, max(p.depth, 0))
+}
+
+// hasInlineHtml reports whether node has inline HTML anywhere in it.
+func hasInlineHtml(node *parser.Node) bool {
+	found := false
+	parser.Inspect(node, func(element parser.Element) bool {
+		if t, ok := element.(*parser.Token); ok && t.Kind == lexer.TInlineHtml {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func lineBreaks(text string) int {
+	return strings.Count(text, "\n") + strings.Count(text, "\r") - strings.Count(text, "\r\n")
+}
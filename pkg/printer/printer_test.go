@@ -0,0 +1,203 @@
+package printer
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"github.com/byawitz/gint/pkg/source"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func printSource(t *testing.T, config Config, content string) string {
+	t.Helper()
+
+	file, diagnostics := parser.Parse(content, lexer.Options{})
+	if len(diagnostics) > 0 {
+		t.Fatalf("parsing %q: %s", content, diagnostics[0].Message)
+	}
+
+	var output strings.Builder
+	if err := config.Fprint(&output, file); err != nil {
+		t.Fatal(err)
+	}
+	return output.String()
+}
+
+func TestPrint(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "statements and control structures",
+			source:   "<?php\nif($a){echo 1;}else{foo( $b,$c );}\nwhile($i<10)$i++;",
+			expected: "<?php\nif ($a) {\n    echo 1;\n} else {\n    foo($b, $c);\n}\nwhile ($i < 10)\n    $i++;\n",
+		},
+		{
+			name:     "class and method braces",
+			source:   "<?php\nclass A extends B{public function f(int $a):?int{return -$a;}}",
+			expected: "<?php\nclass A extends B\n{\n    public function f(int $a): ?int\n    {\n        return -$a;\n    }\n}\n",
+		},
+		{
+			name:     "namespace and use block",
+			source:   "<?php\nnamespace App;\nuse Foo;\nuse Bar\\{Baz,Qux};\nnew Foo;",
+			expected: "<?php\nnamespace App;\n\nuse Foo;\nuse Bar\\{Baz, Qux};\n\nnew Foo;\n",
+		},
+		{
+			name:     "closures and arrow functions",
+			source:   "<?php\n$f=function($a)use(&$b){return $a;};\n$g=static fn($x)=>$x*2;",
+			expected: "<?php\n$f = function ($a) use (&$b) {\n    return $a;\n};\n$g = static fn ($x) => $x * 2;\n",
+		},
+		{
+			name:     "comments stay in place",
+			source:   "<?php\n// first\n$a = 1; // trailing\n\n/* before */ $b = 2;\nfunction f() {\n    return; // last\n    // end of block\n}",
+			expected: "<?php\n// first\n$a = 1; // trailing\n\n/* before */ $b = 2;\nfunction f()\n{\n    return; // last\n    // end of block\n}\n",
+		},
+		{
+			name:     "doc comments are indented again",
+			source:   "<?php\nclass A {\n/**\n       * Doc.\n       */\npublic $a;\n}",
+			expected: "<?php\nclass A\n{\n    /**\n     * Doc.\n     */\n    public $a;\n}\n",
+		},
+		{
+			name:     "lists split across lines",
+			source:   "<?php\n$a = [\n1,\n2,\n];\nfoo(1,\n2);",
+			expected: "<?php\n$a = [\n    1,\n    2,\n];\nfoo(1, 2);\n",
+		},
+		{
+			name:     "multiline parameters keep the brace with the parenthesis",
+			source:   "<?php\nfunction f(\n$a,\n$b\n): void {}",
+			expected: "<?php\nfunction f(\n    $a,\n    $b\n): void {\n}\n",
+		},
+		{
+			name:     "method chains keep their line breaks",
+			source:   "<?php\n$q = $db->table('a')\n->where('b', 1)\n->get();",
+			expected: "<?php\n$q = $db->table('a')\n    ->where('b', 1)\n    ->get();\n",
+		},
+		{
+			name:     "switch and match",
+			source:   "<?php\nswitch($a){case 1:break;default:return;}\necho match($a){1=>'x',default=>'y'};",
+			expected: "<?php\nswitch ($a) {\n    case 1:\n        break;\n    default:\n        return;\n}\necho match ($a) { 1 => 'x', default => 'y' };\n",
+		},
+		{
+			name:     "alternative syntax",
+			source:   "<?php\nif ($a):\necho 1;\nelse:\necho 2;\nendif;",
+			expected: "<?php\nif ($a):\n    echo 1;\nelse:\n    echo 2;\nendif;\n",
+		},
+		{
+			name:     "strings and heredocs are kept",
+			source:   "<?php\n$a = \"x {$b [ 0 ]}\" . <<<EOT\n  body $c\n  EOT;",
+			expected: "<?php\n$a = \"x {$b [ 0 ]}\" . <<<EOT\n  body $c\n  EOT;\n",
+		},
+		{
+			name:     "templates are kept",
+			source:   "<ul>\n<?php foreach ($items as $item): ?>\n  <li><?= $item ?></li>\n<?php endforeach ?>\n</ul>",
+			expected: "<ul>\n<?php foreach ($items as $item): ?>\n  <li><?= $item ?></li>\n<?php endforeach ?>\n</ul>",
+		},
+		{
+			name:     "open tag alone on the first line",
+			source:   "<?php namespace App\\Models;\nclass A {}",
+			expected: "<?php\nnamespace App\\Models;\n\nclass A\n{\n}\n",
+		},
+		{
+			name:     "open tag of templates stays on its line",
+			source:   "<?php if ($a): ?>\n<b>x</b>\n<?php endif ?>\n",
+			expected: "<?php if ($a): ?>\n<b>x</b>\n<?php endif ?>\n",
+		},
+		{
+			name:     "types, references and declare",
+			source:   "<?php\ndeclare(strict_types = 1);\nfunction &f(A | (B & C) $a, int & ...$b): static|null {}",
+			expected: "<?php\ndeclare(strict_types=1);\nfunction &f(A|(B&C) $a, int &...$b): static|null\n{\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := printSource(t, PSR12, test.source); got != test.expected {
+				t.Errorf("expected\n%s\ngot\n%s", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestPrintConfig(t *testing.T) {
+	config := Config{Indent: "\t", LineEnding: source.CRLF, ClassBraces: SameLine, FunctionBraces: SameLine, BlockBraces: NextLine}
+	content := "<?php\nclass A { function f() { if ($a) { return; } } }"
+	expected := "<?php\r\nclass A {\r\n\tfunction f() {\r\n\t\tif ($a)\r\n\t\t{\r\n\t\t\treturn;\r\n\t\t}\r\n\t}\r\n}\r\n"
+
+	if got := printSource(t, config, content); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+// TestPrintKeepsTokens checks that removing the space after a unary operator never glues it
+// to the next token, by lexing the output again.
+func TestPrintKeepsTokens(t *testing.T) {
+	content := "<?php\n$a = - -$b;\n$c = + +$d;\n$e = - --$n;\n$f = + ++$n;\n$g = -  - 1;\n$h = ! !$i;\n$j = - $k;\n"
+	expected := "<?php\n$a = - -$b;\n$c = + +$d;\n$e = - --$n;\n$f = + ++$n;\n$g = - -1;\n$h = !!$i;\n$j = -$k;\n"
+
+	printed := printSource(t, PSR12, content)
+	if printed != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, printed)
+	}
+
+	if got, want := kinds(printed), kinds(content); !slices.Equal(got, want) {
+		t.Errorf("printing changed the tokens from %v to %v", want, got)
+	}
+}
+
+// TestPrintKeepsCode checks that printing the test files only changes their whitespace and
+// the indentation inside their comments, and that printing is idempotent.
+func TestPrintKeepsCode(t *testing.T) {
+	err := filepath.WalkDir("../../tests_assets", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".php" {
+			return err
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if _, diagnostics := parser.Parse(string(content), lexer.Options{}); len(diagnostics) > 0 {
+			return nil
+		}
+
+		printed := printSource(t, PSR12, string(content))
+		if code(printed) != code(string(content)) {
+			t.Errorf("%s: printing changed the code", path)
+		}
+		if again := printSource(t, PSR12, printed); again != printed {
+			t.Errorf("%s: printing the printed file changed it", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// kinds returns the kinds of the tokens of content that aren't whitespace.
+func kinds(content string) []lexer.Kind {
+	var kinds []lexer.Kind
+	for _, token := range lexer.Tokenize(content) {
+		if token.Kind != lexer.TWhitespace {
+			kinds = append(kinds, token.Kind)
+		}
+	}
+	return kinds
+}
+
+// code returns the tokens of content without whitespace, in or out of comments.
+func code(content string) string {
+	var tokens []string
+	for _, token := range lexer.Tokenize(content) {
+		if token.Kind != lexer.TWhitespace {
+			tokens = append(tokens, strings.Join(strings.Fields(token.Value), ""))
+		}
+	}
+	return strings.Join(tokens, " ")
+}
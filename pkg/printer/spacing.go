@@ -0,0 +1,107 @@
+package printer
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+)
+
+// spaced reports whether a space goes between prev and t when they are on the same line.
+// Spaces go around binary operators and between words, but not inside brackets, before
+// commas and semicolons, or between a unary operator, a reference or a member access and
+// what it applies to, unless the two would then lex as a single token, as in "- -$a".
+func spaced(prev, t *parser.Token) bool {
+	before, after := prev.Kind, t.Kind
+	parent, prevParent := kindOf(t), kindOf(prev)
+
+	// Names written in parts, as before PHP 8, or as the prefix of a group use, and
+	// declare(strict_types=1) are written without spaces.
+	if before == lexer.TNsSeparator || after == lexer.TNsSeparator || parent == parser.DeclareItem || prevParent == parser.DeclareItem {
+		return false
+	}
+
+	switch after {
+	case lexer.TCloseParen, lexer.TCloseBracket, lexer.TComma, lexer.TSemiColon, lexer.TObjectOperator,
+		lexer.TNullSafeObjectOperator, lexer.TPaamayimNekudotayim:
+		return false
+	}
+
+	switch before {
+	case lexer.TOpenParen, lexer.TOpenBracket, lexer.TAttribute, lexer.TObjectOperator, lexer.TNullSafeObjectOperator,
+		lexer.TPaamayimNekudotayim, lexer.TDollar, lexer.TEllipsis, lexer.TDollarOpenCurlyBraces, lexer.TCurlyOpen:
+		return false
+	}
+
+	switch {
+	case prevParent == parser.Unary && isFirstChild(prev) && !merges(prev, t), parent == parser.Postfix && !isFirstChild(t):
+		return false
+	case isTypeOperator(after) && (parent == parser.UnionType || parent == parser.IntersectionType),
+		isTypeOperator(before) && (prevParent == parser.UnionType || prevParent == parser.IntersectionType):
+		return false
+	case before == lexer.TQuestion && prevParent == parser.NullableType:
+		return false
+	case isAmpersand(before) && prevParent != parser.Binary && !merges(prev, t):
+		return false
+	}
+
+	switch after {
+	case lexer.TColon:
+		return parent == parser.Ternary && before != lexer.TQuestion
+	case lexer.TOpenParen:
+		return spacedParenthesis(prev, t)
+	case lexer.TOpenBracket:
+		return parent != parser.ArrayDimensionFetch
+	case lexer.TCloseCurly:
+		return parent == parser.Match
+	}
+
+	if before == lexer.TOPENCurly {
+		return prevParent == parser.Match
+	}
+	return true
+}
+
+// spacedParenthesis reports whether a space goes before the opening parenthesis t: it does
+// after control structure keywords, "function" and "fn" of closures and "use", and before
+// a parenthesized expression, but not before the arguments of calls.
+func spacedParenthesis(prev, t *parser.Token) bool {
+	switch kindOf(t) {
+	case parser.If, parser.ElseIf, parser.While, parser.DoWhile, parser.For, parser.Foreach, parser.Switch,
+		parser.Catch, parser.Match, parser.ClosureUses, parser.Paren, parser.IntersectionType:
+		return true
+	case parser.Parameters:
+		owner := t.Parent().Parent()
+		return owner != nil && (owner.Kind == parser.Closure || owner.Kind == parser.ArrowFunction) && !isAmpersand(prev.Kind)
+	}
+	return false
+}
+
+// kindOf returns the kind of the parent of t, or -1 if it has none.
+func kindOf(t *parser.Token) parser.NodeKind {
+	if parent := t.Parent(); parent != nil {
+		return parent.Kind
+	}
+	return -1
+}
+
+// merges reports whether the operator prev and t would lex as a single token without a space
+// between them, like "-" and "-" or "+" and "++".
+func merges(prev, t *parser.Token) bool {
+	if prev.Value == "" || t.Value == "" {
+		return false
+	}
+	last, first := prev.Value[len(prev.Value)-1], t.Value[0]
+	return last == first && (first == '-' || first == '+' || first == '&')
+}
+
+func isFirstChild(t *parser.Token) bool {
+	parent := t.Parent()
+	return parent != nil && len(parent.Children) > 0 && parent.Children[0] == parser.Element(t)
+}
+
+func isAmpersand(kind lexer.Kind) bool {
+	return kind == lexer.TAmpersandFollowedByVarOrVararg || kind == lexer.TAmpersandNotFollowedByVarOrVararg
+}
+
+func isTypeOperator(kind lexer.Kind) bool {
+	return kind == lexer.TPipe || isAmpersand(kind)
+}
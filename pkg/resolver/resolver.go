@@ -0,0 +1,419 @@
+// Package resolver finds out what the names in a PHP file refer to.
+//
+// It follows the namespace and use statements of a syntax tree from the parser package, in
+// the order php compiles them, and resolves every class, function and constant reference
+// to its fully qualified name, along with the import it went through. Fully qualified names
+// are given without their leading backslash, like "App\Models\User".
+package resolver
+
+import (
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"strings"
+)
+
+// Kind is what a name refers to. Classes stand for interfaces, traits and enums too.
+type Kind int
+
+const (
+	Class Kind = iota
+	Function
+	Constant
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Function:
+		return "function"
+	case Constant:
+		return "constant"
+	}
+	return "class"
+}
+
+// Info is what Resolve finds out about a file.
+type Info struct {
+	// Namespaces are the namespaces of the file in order. Code outside of any namespace
+	// declaration is in a global namespace, with an empty name.
+	Namespaces []*Namespace
+	// Declarations are the classes, functions and constants the file declares.
+	Declarations []*Declaration
+	// References are the names referring to classes, functions and constants, in source
+	// order.
+	References []*Reference
+
+	references map[*parser.Node]*Reference
+}
+
+// Namespace is a namespace of a file, with what it imports.
+type Namespace struct {
+	Name string
+	// Node is the namespace statement, nil for code outside of any namespace.
+	Node    *parser.Node
+	Imports []*Import
+}
+
+// Import is a name imported by a use statement.
+type Import struct {
+	Kind Kind
+	// Name is the fully qualified name imported.
+	Name string
+	// Alias is the name the import is used by: the one after "as", or the last part of
+	// Name.
+	Alias string
+	// Clause is the UseClause node of the import, and Statement the Use node it is part of.
+	Clause    *parser.Node
+	Statement *parser.Node
+	// References are the references resolved through the import.
+	References []*Reference
+}
+
+// Declaration is a class, function or constant declared in a file.
+type Declaration struct {
+	Kind Kind
+	// Name is the fully qualified name declared.
+	Name string
+	// Node is the declaration, or the ConstElement node of a constant.
+	Node *parser.Node
+	// Identifier is the token with the name as written in the declaration.
+	Identifier *parser.Token
+}
+
+// Reference is a name in the code referring to a class, function or constant.
+type Reference struct {
+	Kind Kind
+	// Node is the Name node of the reference.
+	Node *parser.Node
+	// FullyQualified is the name the reference resolves to.
+	FullyQualified string
+	// Fallback is the global name php falls back to at runtime for an unqualified function
+	// or constant that is neither imported nor declared in its namespace. It is empty when
+	// there is no fallback.
+	Fallback string
+	// Import is the import the name was resolved through, or nil.
+	Import *Import
+	// Namespace is the namespace the reference is in.
+	Namespace *Namespace
+}
+
+// Name returns the reference as written.
+func (r *Reference) Name() string {
+	return r.Node.Text()
+}
+
+// Reference returns the reference of a Name node, or nil if the node doesn't refer to a
+// class, function or constant.
+func (i *Info) Reference(name *parser.Node) *Reference {
+	return i.references[name]
+}
+
+// Resolve resolves the names of a file, given by its File node.
+func Resolve(file *parser.Node) *Info {
+	info := &Info{references: map[*parser.Node]*Reference{}}
+
+	global := &Namespace{}
+	info.Namespaces = append(info.Namespaces, global)
+	parser.Walk(&scope{info: info, namespace: global}, file)
+
+	return info
+}
+
+// scope visits a namespace, resolving names against its imports.
+type scope struct {
+	info      *Info
+	namespace *Namespace
+}
+
+func (s *scope) Visit(element parser.Element) parser.Visitor {
+	n, ok := element.(*parser.Node)
+	if !ok {
+		return nil
+	}
+
+	switch n.Kind {
+	case parser.Namespace:
+		namespace := &Namespace{Node: n}
+		if name := n.Node(parser.Name); name != nil {
+			namespace.Name = name.Text()
+		}
+		s.info.Namespaces = append(s.info.Namespaces, namespace)
+		return &scope{info: s.info, namespace: namespace}
+	case parser.Use:
+		s.imports(n)
+		return nil
+	case parser.Name:
+		s.reference(n)
+		return nil
+	case parser.ClassDeclaration, parser.InterfaceDeclaration, parser.TraitDeclaration, parser.EnumDeclaration:
+		s.declare(Class, n, identifierAfter(n, lexer.TClass, lexer.TInterface, lexer.TTrait, lexer.TEnum))
+	case parser.FunctionDeclaration:
+		s.declare(Function, n, identifierAfter(n, lexer.TFunction, lexer.TAmpersandFollowedByVarOrVararg, lexer.TAmpersandNotFollowedByVarOrVararg))
+	case parser.ConstStatement:
+		for _, element := range n.Nodes() {
+			if element.Kind == parser.ConstElement {
+				s.declare(Constant, element, element.FirstToken())
+			}
+		}
+	}
+	return s
+}
+
+// imports adds the imports of a use statement to the namespace.
+func (s *scope) imports(n *parser.Node) {
+	kind := importKind(n)
+
+	prefix := ""
+	if n.Token(lexer.TOPENCurly) != nil {
+		if name := n.Node(parser.Name); name != nil {
+			prefix = strings.Trim(name.Text(), `\`) + `\`
+		}
+	}
+
+	for _, clause := range n.Nodes() {
+		if clause.Kind != parser.UseClause {
+			continue
+		}
+
+		name := clause.Node(parser.Name)
+		if name == nil {
+			continue
+		}
+
+		imported := &Import{Kind: kind, Name: prefix + strings.Trim(name.Text(), `\`), Clause: clause, Statement: n}
+		if clause.Token(lexer.TFunction, lexer.TConst) != nil {
+			imported.Kind = importKind(clause)
+		}
+
+		imported.Alias = lastPart(imported.Name)
+		if as := clause.Token(lexer.TAs); as != nil {
+			if alias := tokenAfter(clause, as); alias != nil {
+				imported.Alias = alias.Value
+			}
+		}
+
+		s.namespace.Imports = append(s.namespace.Imports, imported)
+	}
+}
+
+func (s *scope) declare(kind Kind, n *parser.Node, identifier *parser.Token) {
+	if identifier == nil {
+		return
+	}
+
+	s.info.Declarations = append(s.info.Declarations, &Declaration{
+		Kind:       kind,
+		Name:       s.qualify(identifier.Value),
+		Node:       n,
+		Identifier: identifier,
+	})
+}
+
+// reference resolves a name, if it refers to a class, function or constant.
+func (s *scope) reference(name *parser.Node) {
+	kind, ok := referenceKind(name)
+	if !ok {
+		return
+	}
+
+	text := name.Text()
+	if kind == Class && isSpecialClass(text) || kind == Constant && isSpecialConstant(text) {
+		return
+	}
+
+	reference := &Reference{Kind: kind, Node: name, Namespace: s.namespace}
+	s.resolve(reference, text)
+
+	s.info.References = append(s.info.References, reference)
+	s.info.references[name] = reference
+	if reference.Import != nil {
+		reference.Import.References = append(reference.Import.References, reference)
+	}
+}
+
+// resolve sets the fully qualified name of a reference written as text, following php's
+// rules: a fully qualified name is taken as it is, the first part of a qualified name may be
+// a class import, and an unqualified name may be an import of its own kind.
+func (s *scope) resolve(reference *Reference, text string) {
+	switch {
+	case strings.HasPrefix(text, `\`):
+		reference.FullyQualified = text[1:]
+		return
+	case len(text) > len(`namespace\`) && strings.EqualFold(text[:len(`namespace\`)], `namespace\`):
+		reference.FullyQualified = s.qualify(text[len(`namespace\`):])
+		return
+	}
+
+	if first, rest, qualified := strings.Cut(text, `\`); qualified {
+		if imported := s.lookup(Class, first); imported != nil {
+			reference.FullyQualified = imported.Name + `\` + rest
+			reference.Import = imported
+			return
+		}
+		reference.FullyQualified = s.qualify(text)
+		return
+	}
+
+	if imported := s.lookup(reference.Kind, text); imported != nil {
+		reference.FullyQualified = imported.Name
+		reference.Import = imported
+		return
+	}
+
+	reference.FullyQualified = s.qualify(text)
+	if reference.Kind != Class && s.namespace.Name != "" && !s.declares(reference.Kind, reference.FullyQualified) {
+		reference.Fallback = text
+	}
+}
+
+// lookup returns the import of the kind with the alias, the last one if it is imported more
+// than once. Aliases of constants are case-sensitive, those of classes and functions aren't.
+func (s *scope) lookup(kind Kind, alias string) *Import {
+	for i := len(s.namespace.Imports) - 1; i >= 0; i-- {
+		imported := s.namespace.Imports[i]
+		if imported.Kind != kind {
+			continue
+		}
+
+		if imported.Alias == alias || kind != Constant && strings.EqualFold(imported.Alias, alias) {
+			return imported
+		}
+	}
+	return nil
+}
+
+// declares reports whether the file declares name, of the kind, before the current point.
+func (s *scope) declares(kind Kind, name string) bool {
+	for _, declaration := range s.info.Declarations {
+		if declaration.Kind == kind && (declaration.Name == name || kind == Function && strings.EqualFold(declaration.Name, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *scope) qualify(name string) string {
+	if s.namespace.Name == "" {
+		return name
+	}
+	return s.namespace.Name + `\` + name
+}
+
+// referenceKind returns what a Name node refers to, from where it is in the tree. It
+// returns false for names that aren't references, like those of namespaces.
+func referenceKind(name *parser.Node) (Kind, bool) {
+	parent := name.Parent()
+	if parent == nil {
+		return 0, false
+	}
+
+	first := len(parent.Children) > 0 && parent.Children[0] == parser.Element(name)
+
+	switch parent.Kind {
+	case parser.Call:
+		return Function, first
+	case parser.ConstantFetch:
+		if isInstanceofClass(parent) {
+			return Class, true
+		}
+		return Constant, true
+	case parser.StaticCall, parser.ClassConstantFetch, parser.StaticPropertyFetch:
+		return Class, first
+	case parser.NamedType:
+		return Class, !isBuiltinType(name.Text())
+	case parser.New, parser.Extends, parser.Implements, parser.Attribute, parser.Catch, parser.TraitUse, parser.TraitAdaptation:
+		return Class, true
+	}
+	return 0, false
+}
+
+// isInstanceofClass reports whether a constant fetch is the class on the right of an
+// instanceof, which the parser can't tell from a constant.
+func isInstanceofClass(fetch *parser.Node) bool {
+	parent := fetch.Parent()
+	if parent == nil || parent.Kind != parser.Binary || parent.Token(lexer.TInstanceof) == nil {
+		return false
+	}
+	return parent.Children[len(parent.Children)-1] == parser.Element(fetch)
+}
+
+func importKind(n *parser.Node) Kind {
+	switch {
+	case n.Token(lexer.TFunction) != nil:
+		return Function
+	case n.Token(lexer.TConst) != nil:
+		return Constant
+	}
+	return Class
+}
+
+// identifierAfter returns the first token of n after the last of its leading tokens of
+// one of the kinds, such as the name after "class" or "function &".
+func identifierAfter(n *parser.Node, kinds ...lexer.Kind) *parser.Token {
+	seen := false
+	for _, child := range n.Children {
+		t, ok := child.(*parser.Token)
+		switch {
+		case !ok:
+			if seen {
+				return nil
+			}
+		case isOneOf(t.Kind, kinds):
+			seen = true
+		case seen:
+			return t
+		}
+	}
+	return nil
+}
+
+// tokenAfter returns the token right after the child t of n.
+func tokenAfter(n *parser.Node, t *parser.Token) *parser.Token {
+	for i, child := range n.Children {
+		if child == parser.Element(t) && i+1 < len(n.Children) {
+			next, _ := n.Children[i+1].(*parser.Token)
+			return next
+		}
+	}
+	return nil
+}
+
+func lastPart(name string) string {
+	return name[strings.LastIndex(name, `\`)+1:]
+}
+
+func isOneOf(kind lexer.Kind, kinds []lexer.Kind) bool {
+	for _, k := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpecialClass reports whether name is one of the class names resolved at runtime.
+func isSpecialClass(name string) bool {
+	switch strings.ToLower(name) {
+	case "self", "static", "parent":
+		return true
+	}
+	return false
+}
+
+// isSpecialConstant reports whether name is one of the constants php treats as literals.
+func isSpecialConstant(name string) bool {
+	switch strings.ToLower(name) {
+	case "true", "false", "null":
+		return true
+	}
+	return false
+}
+
+// isBuiltinType reports whether a type name is one of php's own types rather than a class.
+func isBuiltinType(name string) bool {
+	switch strings.ToLower(name) {
+	case "array", "bool", "callable", "false", "float", "int", "iterable", "mixed", "never", "null",
+		"object", "parent", "self", "static", "string", "true", "void":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package resolver
+
+import (
+	"fmt"
+	"github.com/byawitz/gint/pkg/lexer"
+	"github.com/byawitz/gint/pkg/parser"
+	"strings"
+	"testing"
+)
+
+func resolve(t *testing.T, source string) *Info {
+	t.Helper()
+
+	file, diagnostics := parser.Parse(source, lexer.Options{})
+	if len(diagnostics) > 0 {
+		t.Fatalf("unexpected syntax errors: %v", diagnostics)
+	}
+	return Resolve(file)
+}
+
+// references lists the references of info as "kind name => fully qualified".
+func references(info *Info) []string {
+	var list []string
+	for _, reference := range info.References {
+		line := fmt.Sprintf("%s %s => %s", reference.Kind, reference.Name(), reference.FullyQualified)
+		if reference.Fallback != "" {
+			line += " | " + reference.Fallback
+		}
+		list = append(list, line)
+	}
+	return list
+}
+
+func TestResolveReferences(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected []string
+	}{
+		{
+			name:   "global code",
+			source: `<?php new Foo(); strlen($a); \App\Bar::X; PHP_EOL;`,
+			expected: []string{
+				"class Foo => Foo",
+				"function strlen => strlen",
+				"class \\App\\Bar => App\\Bar",
+				"constant PHP_EOL => PHP_EOL",
+			},
+		},
+		{
+			name:   "namespace",
+			source: `<?php namespace App; new Foo(); strlen($a); Sub\Bar::X; namespace\Baz::x(); PHP_EOL;`,
+			expected: []string{
+				"class Foo => App\\Foo",
+				"function strlen => App\\strlen | strlen",
+				"class Sub\\Bar => App\\Sub\\Bar",
+				"class namespace\\Baz => App\\Baz",
+				"constant PHP_EOL => App\\PHP_EOL | PHP_EOL",
+			},
+		},
+		{
+			name: "imports",
+			source: `<?php
+namespace App;
+
+use Illuminate\Support\Collection;
+use Illuminate\Support\Facades as F;
+use function Illuminate\Support\collect as make;
+use const Illuminate\Support\VERSION;
+
+collection::make(make([]));
+F\Route::get(VERSION, version);`,
+			expected: []string{
+				"class collection => Illuminate\\Support\\Collection",
+				"function make => Illuminate\\Support\\collect",
+				"class F\\Route => Illuminate\\Support\\Facades\\Route",
+				"constant VERSION => Illuminate\\Support\\VERSION",
+				"constant version => App\\version | version",
+			},
+		},
+		{
+			name: "group imports",
+			source: `<?php
+namespace App;
+
+use App\Models\{User, Post as Article, function helper, const LIMIT};
+use function App\Support\{first, last};
+
+new User(); new Article(); helper(LIMIT); first(); last();`,
+			expected: []string{
+				"class User => App\\Models\\User",
+				"class Article => App\\Models\\Post",
+				"function helper => App\\Models\\helper",
+				"constant LIMIT => App\\Models\\LIMIT",
+				"function first => App\\Support\\first",
+				"function last => App\\Support\\last",
+			},
+		},
+		{
+			name: "class contexts",
+			source: `<?php
+namespace App;
+
+use Countable, Stringable;
+
+#[Attribute]
+class Foo extends Base implements Countable, Stringable
+{
+    use Macroable;
+
+    public function bar(self $a, ?Request $b, int|Collection $c): static
+    {
+        try {
+            return $a instanceof Model ? parent::bar() : null;
+        } catch (FooException|\RuntimeException $e) {
+            return static::$cache;
+        }
+    }
+}`,
+			expected: []string{
+				"class Attribute => App\\Attribute",
+				"class Base => App\\Base",
+				"class Countable => Countable",
+				"class Stringable => Stringable",
+				"class Macroable => App\\Macroable",
+				"class Request => App\\Request",
+				"class Collection => App\\Collection",
+				"class Model => App\\Model",
+				"class FooException => App\\FooException",
+				"class \\RuntimeException => RuntimeException",
+			},
+		},
+		{
+			name: "declared functions have no fallback",
+			source: `<?php
+namespace App;
+
+function helper() {}
+const LIMIT = 1;
+
+helper(LIMIT);`,
+			expected: []string{
+				"function helper => App\\helper",
+				"constant LIMIT => App\\LIMIT",
+			},
+		},
+		{
+			name: "imports apply to their own namespace",
+			source: `<?php
+namespace A {
+    use X\Foo;
+    new Foo();
+}
+namespace B {
+    new Foo();
+}`,
+			expected: []string{
+				"class Foo => X\\Foo",
+				"class Foo => B\\Foo",
+			},
+		},
+		{
+			name:   "constant aliases are case-sensitive",
+			source: `<?php namespace App; use const Lib\LIMIT; use Lib\Thing; limit; new THING();`,
+			expected: []string{
+				"constant limit => App\\limit | limit",
+				"class THING => Lib\\Thing",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := references(resolve(t, test.source))
+			if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(test.expected, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestResolveImports(t *testing.T) {
+	info := resolve(t, `<?php
+namespace App;
+
+use Foo\Bar, Foo\Baz as Qux;
+use function Foo\unused;
+
+new Bar(); Bar::x(); Qux::y();`)
+
+	if len(info.Namespaces) != 2 || info.Namespaces[1].Name != "App" {
+		t.Fatalf("expected the global and App namespaces, got %d", len(info.Namespaces))
+	}
+
+	var got []string
+	for _, imported := range info.Namespaces[1].Imports {
+		got = append(got, fmt.Sprintf("%s %s as %s: %d", imported.Kind, imported.Name, imported.Alias, len(imported.References)))
+		if imported.Statement.Kind != parser.Use || imported.Clause.Kind != parser.UseClause {
+			t.Errorf("unexpected nodes for %s: %s, %s", imported.Name, imported.Statement.Kind, imported.Clause.Kind)
+		}
+	}
+
+	expected := "class Foo\\Bar as Bar: 2, class Foo\\Baz as Qux: 1, function Foo\\unused as unused: 0"
+	if strings.Join(got, ", ") != expected {
+		t.Errorf("expected %q, got %q", expected, strings.Join(got, ", "))
+	}
+
+	for _, reference := range info.References {
+		if info.Reference(reference.Node) != reference {
+			t.Errorf("reference of %s not found by its node", reference.Name())
+		}
+	}
+}
+
+func TestResolveDeclarations(t *testing.T) {
+	info := resolve(t, `<?php
+namespace App\Models;
+
+final class User {}
+interface HasName {}
+trait Named {}
+enum Status: string {}
+function &helper() {}
+const A = 1, B = 2;
+
+$anonymous = new class {};`)
+
+	var got []string
+	for _, declaration := range info.Declarations {
+		got = append(got, fmt.Sprintf("%s %s", declaration.Kind, declaration.Name))
+	}
+
+	expected := []string{
+		"class App\\Models\\User",
+		"class App\\Models\\HasName",
+		"class App\\Models\\Named",
+		"class App\\Models\\Status",
+		"function App\\Models\\helper",
+		"constant App\\Models\\A",
+		"constant App\\Models\\B",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+}
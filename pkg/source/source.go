@@ -0,0 +1,167 @@
+// Package source reads and writes PHP files in the encoding, byte order mark and line
+// endings they came with, so the rest of gint only deals with UTF-8 text.
+package source
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/byawitz/gint/pkg/lexer"
+	"strings"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a file.
+type Encoding int
+
+const (
+	UTF8 Encoding = iota
+	// ISO88591 is Latin-1, where every byte is a character. Files that aren't valid UTF-8
+	// are taken to be in it, as legacy PHP views usually are.
+	ISO88591
+)
+
+func (e Encoding) String() string {
+	if e == ISO88591 {
+		return "ISO-8859-1"
+	}
+	return "UTF-8"
+}
+
+// LineEnding is the byte sequence ending the lines of a file.
+type LineEnding string
+
+const (
+	LF   LineEnding = "\n"
+	CRLF LineEnding = "\r\n"
+	CR   LineEnding = "\r"
+)
+
+func (l LineEnding) String() string {
+	switch l {
+	case CRLF:
+		return "CRLF"
+	case CR:
+		return "CR"
+	}
+	return "LF"
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ErrUTF16 is returned for files starting with a UTF-16 byte order mark, which php can't run.
+var ErrUTF16 = errors.New("UTF-16 files aren't supported")
+
+// File is the decoded content of a file together with how it was encoded.
+type File struct {
+	// Content is the text of the file in UTF-8, without its byte order mark.
+	Content    string
+	Encoding   Encoding
+	BOM        bool
+	LineEnding LineEnding
+}
+
+// Decode detects the encoding, byte order mark and line endings of raw and decodes it.
+func Decode(raw []byte) (File, error) {
+	if bytes.HasPrefix(raw, []byte{0xFF, 0xFE}) || bytes.HasPrefix(raw, []byte{0xFE, 0xFF}) {
+		return File{}, ErrUTF16
+	}
+
+	file := File{Encoding: UTF8}
+	if bytes.HasPrefix(raw, utf8BOM) {
+		file.BOM = true
+		raw = raw[len(utf8BOM):]
+	}
+
+	if utf8.Valid(raw) {
+		file.Content = string(raw)
+	} else {
+		file.Encoding = ISO88591
+		file.Content = decodeLatin1(raw)
+	}
+
+	file.LineEnding = DetectLineEnding(file.Content)
+	return file, nil
+}
+
+// Encode encodes content like the file was, with its encoding and byte order mark.
+// It fails if content has characters the encoding can't represent.
+func (f File) Encode(content string) ([]byte, error) {
+	var encoded []byte
+	if f.BOM {
+		encoded = append(encoded, utf8BOM...)
+	}
+
+	if f.Encoding == UTF8 {
+		return append(encoded, content...), nil
+	}
+
+	for i, r := range content {
+		if r > 0xFF {
+			return nil, fmt.Errorf("character %q at offset %d can't be written as %s", r, i, f.Encoding)
+		}
+		encoded = append(encoded, byte(r))
+	}
+	return encoded, nil
+}
+
+func decodeLatin1(raw []byte) string {
+	var builder strings.Builder
+	builder.Grow(len(raw))
+
+	for _, b := range raw {
+		builder.WriteRune(rune(b))
+	}
+	return builder.String()
+}
+
+// DetectLineEnding returns the line ending used the most in content, LF if there is no
+// line break at all.
+func DetectLineEnding(content string) LineEnding {
+	crlf := strings.Count(content, "\r\n")
+	lf := strings.Count(content, "\n") - crlf
+	cr := strings.Count(content, "\r") - crlf
+
+	switch {
+	case crlf > lf && crlf >= cr:
+		return CRLF
+	case cr > lf && cr > crlf:
+		return CR
+	}
+	return LF
+}
+
+// ConvertLineEndings joins tokens back into source, with every line break outside string
+// literals and inline HTML turned into ending. Line breaks inside strings and heredoc bodies
+// are part of their value, and those of inline HTML are output by the script, so they are
+// kept.
+func ConvertLineEndings(tokens []lexer.Token, ending LineEnding) string {
+	var builder strings.Builder
+
+	for _, token := range tokens {
+		if token.Kind == lexer.TConstantEncapsedString || token.Kind == lexer.TEncapsedAndWhitespace || token.Kind == lexer.TInlineHtml {
+			builder.WriteString(token.Value)
+			continue
+		}
+		writeLines(&builder, token.Value, ending)
+	}
+	return builder.String()
+}
+
+func writeLines(builder *strings.Builder, value string, ending LineEnding) {
+	for {
+		i := strings.IndexAny(value, "\r\n")
+		if i == -1 {
+			builder.WriteString(value)
+			return
+		}
+
+		builder.WriteString(value[:i])
+		builder.WriteString(string(ending))
+
+		if strings.HasPrefix(value[i:], "\r\n") {
+			i++
+		}
+		value = value[i+1:]
+	}
+}
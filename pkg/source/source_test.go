@@ -0,0 +1,62 @@
+package source
+
+import (
+	"bytes"
+	"errors"
+	"github.com/byawitz/gint/pkg/lexer"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		raw        string
+		content    string
+		encoding   Encoding
+		bom        bool
+		lineEnding LineEnding
+	}{
+		{"<?php\necho 1;\n", "<?php\necho 1;\n", UTF8, false, LF},
+		{"\xEF\xBB\xBF<?php\r\necho 'é';\r\n", "<?php\r\necho 'é';\r\n", UTF8, true, CRLF},
+		{"<?php\recho 1;\r", "<?php\recho 1;\r", UTF8, false, CR},
+		{"<p>caf\xE9</p>\n", "<p>café</p>\n", ISO88591, false, LF},
+		{"<?php", "<?php", UTF8, false, LF},
+	}
+
+	for _, c := range cases {
+		file, err := Decode([]byte(c.raw))
+		if err != nil {
+			t.Fatalf("Decoding %q failed: %v", c.raw, err)
+		}
+
+		if file.Content != c.content || file.Encoding != c.encoding || file.BOM != c.bom || file.LineEnding != c.lineEnding {
+			t.Errorf("Decoding %q: got %q %s bom=%v %s, want %q %s bom=%v %s", c.raw, file.Content, file.Encoding, file.BOM, file.LineEnding, c.content, c.encoding, c.bom, c.lineEnding)
+		}
+
+		encoded, err := file.Encode(file.Content)
+		if err != nil || !bytes.Equal(encoded, []byte(c.raw)) {
+			t.Errorf("Encoding %q back: got %q, %v", c.raw, encoded, err)
+		}
+	}
+}
+
+func TestDecodeUTF16(t *testing.T) {
+	if _, err := Decode([]byte("\xFF\xFE<\x00?\x00")); !errors.Is(err, ErrUTF16) {
+		t.Fatalf("Decoding UTF-16: got %v, want %v", err, ErrUTF16)
+	}
+}
+
+func TestEncodeUnrepresentable(t *testing.T) {
+	file := File{Encoding: ISO88591}
+	if _, err := file.Encode("<?php echo '€';"); err == nil {
+		t.Fatal("Encoding € as ISO-8859-1: got nil, want error")
+	}
+}
+
+func TestConvertLineEndings(t *testing.T) {
+	source := "<p>\n</p>\n<?php\n// comment\r\n$a = \"x\ny\";\n$b = <<<EOT\n  one\r\n  EOT;\r?>\n<br>\n"
+	expected := "<p>\n</p>\n<?php\r\n// comment\r\n$a = \"x\ny\";\r\n$b = <<<EOT\r\n  one\r\n  EOT;\r\n?>\r\n<br>\n"
+
+	if got := ConvertLineEndings(lexer.Tokenize(source), CRLF); got != expected {
+		t.Fatalf("Converting line endings: got %q, want %q", got, expected)
+	}
+}
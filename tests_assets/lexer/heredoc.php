@@ -0,0 +1,20 @@
+<?php
+
+$query = <<<SQL
+    SELECT id, email
+    FROM users
+    WHERE status = '{$status}'
+      AND created_at > "$since"
+    SQL;
+
+$mail = <<<"MAIL"
+Dear {$user->name},
+
+Your order #$order[id] of ${amount} has shipped to $address->city.
+MAIL;
+
+$template = <<<'TPL'
+    <p>Nothing is $interpolated {$here}</p>
+    TPL;
+
+echo $query . $mail . $template;
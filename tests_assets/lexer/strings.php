@@ -0,0 +1,7 @@
+<?php
+
+$plain = 'single \'quoted\' string';
+$double = "say \"hi\" to {$user->name} and $friends[0], ${greeting}";
+$escaped = "no \$interpolation \{$here} either";
+$command = `git log --format=$format`;
+$empty = "" . '' . ``;
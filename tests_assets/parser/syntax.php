@@ -0,0 +1,222 @@
+<!DOCTYPE html>
+<?php
+
+declare(strict_types=1);
+
+namespace App\Models;
+
+use App\Contracts\{HasName, HasAge as Aged};
+use function App\Support\{format, trim as strip};
+use const App\Support\VERSION, App\Support\DEBUG;
+use Closure;
+
+const LIMIT = 10, OFFSET = LIMIT * 2;
+
+#[\Attribute(\Attribute::TARGET_CLASS)]
+final readonly class User extends Model implements HasName, Aged
+{
+    use HasFactory, SoftDeletes {
+        HasFactory::make insteadof SoftDeletes;
+        SoftDeletes::make as protected softMake;
+        restore as public;
+    }
+
+    public const string TABLE = 'users';
+    final protected const int|float RATE = 1.5;
+
+    private static ?array $cache = null;
+    public int $age = 0, $height;
+    var $legacy;
+
+    public function __construct(
+        #[SensitiveParameter] private string $name,
+        protected readonly int $id = 0,
+        public (Stringable&Countable)|null $tags = null,
+        &...$rest,
+    ) {
+        parent::__construct();
+    }
+
+    abstract protected function build(): static;
+
+    public static function &find(int|string $id, ?callable $then = null): ?self
+    {
+        static $found = [], $count;
+        global $db;
+
+        return self::$cache[$id] ??= new static(...$db->query("SELECT * FROM users WHERE id = {$id}"));
+    }
+
+    public function list(): iterable
+    {
+        yield 1;
+        yield 'key' => $this->name;
+        $received = yield;
+        yield from [2, 3];
+    }
+}
+
+interface Shape extends Countable, \JsonSerializable
+{
+    const SIDES = 0;
+    public function area(): float;
+}
+
+trait Greets
+{
+    abstract public function name(): string;
+    public function greet(): string { return "Hello, {$this->name()} and $this->name[0] or ${name}!"; }
+}
+
+enum Suit: string implements HasLabel
+{
+    use Greets;
+
+    case Hearts = 'H';
+    case Spades = 'S';
+
+    const Wild = self::Spades;
+
+    public function color(): string
+    {
+        return match ($this) {
+            Suit::Hearts, => 'Red',
+            Suit::Spades => 'Black',
+            default => throw new \LogicException(),
+        };
+    }
+}
+
+enum Status
+{
+    case Active;
+    case Inactive;
+}
+
+abstract class Base {}
+
+function &reference(array &$values, int ...$more): never
+{
+    exit(1);
+}
+
+$closure = static function ($a, &$b) use ($c, &$d): int {
+    return $a <=> $b;
+};
+$arrow = fn(int $x): int => $x * 2 + $y ** -2 ** 3;
+$attributed = #[Pure] fn() => null;
+$callable = strlen(...);
+$method = $object?->method(...)?->property;
+$static = Foo::{$name}(...$args);
+$prop = Foo::$bar['baz'];
+$class = $object::class;
+$new = new class(10) extends Base implements Countable {
+    public function count(): int { return 0; }
+};
+$anonymous = new #[Attr] readonly class {};
+$dynamic = new $className->factory['key']($argument);
+$expression = new ('Foo' . 'Bar');
+
+[$a, [, $b], 'c' => $c] = $array;
+list($d, list($e)) = [1, [2]];
+['x' => &$x, ...$rest] = $values;
+
+$ternary = $a ? $b : ($c ?: $d);
+$logic = $a and $b or !$c xor $d;
+$math = -$a + +$b * ~$c / 2 % 3 << 1 >> 2 & 4 | 5 ^ 6;
+$compare = $a == $b && $a != $c || $a === $d && $a !== $e && $a < 1 && $a <= 2 && $a > 3 && $a >= 4 && $a <> 5;
+$string = 'a' . "b" . <<<EOT
+    Heredoc $value {$object->value} ${key}
+    EOT . <<<'EOT'
+    Nowdoc
+    EOT;
+$shell = `ls -la $dir`;
+$casted = (int) $a + (float) $b . (string) $c . (bool) $d . (array) $e . (object) $f;
+$errors = @file_get_contents('file');
+$inc = $i++ + ++$i - $j-- - --$j;
+$instance = $a instanceof Foo && !$b instanceof $c;
+$isset = isset($a, $b['c']) && empty($d) && eval('return 1;');
+$print = print 'hello';
+$clone = clone $object;
+$include = include 'file.php';
+$named = str_pad(string: 'x', length: 10, pad_type: STR_PAD_LEFT);
+$variable = $$name . ${'dynamic'} . $object->{'property'};
+$fqn = \strlen('a') + namespace\helper() + Sub\helper() + \PHP_EOL;
+$magic = __LINE__ . __FILE__ . __DIR__ . __CLASS__ . __FUNCTION__ . __METHOD__ . __NAMESPACE__;
+$a .= 'b';
+$a =& $b;
+$a ??= $b ?? $c;
+
+if ($a) {
+    echo 1;
+} elseif ($b) {
+    echo 2;
+} else if ($c) {
+    echo 3;
+} else {
+    echo 4, 5;
+}
+
+if ($a):
+    echo 1;
+elseif ($b):
+    echo 2;
+else:
+    echo 3;
+endif;
+
+while ($i < 10) $i++;
+while ($i < 10):
+    $i++;
+endwhile;
+
+do {
+    $i--;
+} while ($i > 0);
+
+for ($i = 0, $j = 0; $i < 10; $i++, $j++) {
+    continue;
+}
+for (;;):
+    break 1;
+endfor;
+
+foreach ($items as $key => &$value) {}
+foreach ($items as [$a, $b]):
+endforeach;
+
+switch ($a) {
+    case 1:
+    case 2;
+        break;
+    default:
+        return;
+}
+switch ($a):
+    case 1:
+        break;
+endswitch;
+
+try {
+    throw new Exception('oops');
+} catch (InvalidArgumentException | \TypeError $e) {
+    unset($e, $f[0]);
+} catch (Exception) {
+} finally {
+    goto end;
+}
+
+end:
+declare(ticks=1) {
+    echo 'tick';
+}
+declare(ticks=1):
+enddeclare;
+?>
+<p><?= $title ?></p>
+<?php if ($show): ?>
+    <b>shown</b>
+<?php endif ?>
+<?php
+__halt_compiler();
+Raw data after the compiler halts.